@@ -0,0 +1,418 @@
+package person_api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The accessors below return the value of an attribute and whether it is
+// set, treating nil persons, absent attributes and null values alike.
+
+func stringValue(a StandardAttributeString) (string, bool) {
+	return a.Value, a.Value != ""
+}
+
+// booleanValue cannot tell false from an absent value by the value alone, so
+// a boolean counts as present if its metadata is.
+func booleanValue(a StandardAttributeBoolean) (bool, bool) {
+	return a.Value, a.Value || a.Metadata != (Metadata{})
+}
+
+// valuesMap converts the values of a list-valued attribute. Null values
+// become empty strings.
+func valuesMap(a StandardAttributeValues) (map[string]string, bool) {
+	raw, ok := a.Values.(map[string]interface{})
+	if !ok {
+		return map[string]string{}, false
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case nil:
+			values[k] = ""
+		case string:
+			values[k] = v
+		default:
+			values[k] = fmt.Sprint(v)
+		}
+	}
+	return values, len(values) > 0
+}
+
+func (p *Person) AlternativeNameValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.AlternativeName)
+}
+
+func (p *Person) CreatedValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.Created)
+}
+
+func (p *Person) DescriptionValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.Description)
+}
+
+func (p *Person) FirstNameValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.FirstName)
+}
+
+func (p *Person) FunTitleValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.FunTitle)
+}
+
+func (p *Person) LastModifiedValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.LastModified)
+}
+
+func (p *Person) LastNameValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.LastName)
+}
+
+func (p *Person) LocationValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.Location)
+}
+
+func (p *Person) LoginMethodValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.LoginMethod)
+}
+
+func (p *Person) PictureValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.Picture)
+}
+
+func (p *Person) PrimaryEmailValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.PrimaryEmail)
+}
+
+func (p *Person) PrimaryUsernameValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.PrimaryUsername)
+}
+
+func (p *Person) PronounsValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.Pronouns)
+}
+
+func (p *Person) TimezoneValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.Timezone)
+}
+
+func (p *Person) UserIDValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.UserID)
+}
+
+func (p *Person) UUIDValue() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.UUID)
+}
+
+func (p *Person) ActiveValue() (bool, bool) {
+	if p == nil {
+		return false, false
+	}
+	return booleanValue(p.Active)
+}
+
+func (p *Person) LanguagesValues() (map[string]string, bool) {
+	if p == nil {
+		return map[string]string{}, false
+	}
+	return valuesMap(p.Languages)
+}
+
+func (p *Person) PhoneNumbersValues() (map[string]string, bool) {
+	if p == nil {
+		return map[string]string{}, false
+	}
+	return valuesMap(p.PhoneNumbers)
+}
+
+func (p *Person) TagsValues() (map[string]string, bool) {
+	if p == nil {
+		return map[string]string{}, false
+	}
+	return valuesMap(p.Tags)
+}
+
+func (p *Person) UrisValues() (map[string]string, bool) {
+	if p == nil {
+		return map[string]string{}, false
+	}
+	return valuesMap(p.Uris)
+}
+
+func (p *Person) UsernamesValues() (map[string]string, bool) {
+	if p == nil {
+		return map[string]string{}, false
+	}
+	return valuesMap(p.Usernames)
+}
+
+func (p *Person) SSHPublicKeysValues() (map[string]string, bool) {
+	if p == nil {
+		return map[string]string{}, false
+	}
+	return valuesMap(p.SSHPublicKeys)
+}
+
+func (p *Person) PGPPublicKeysValues() (map[string]string, bool) {
+	if p == nil {
+		return map[string]string{}, false
+	}
+	return valuesMap(p.PGPPublicKeys)
+}
+
+// hrisManagerEmail is the access_information.hris value holding the primary
+// email of a person's manager.
+const hrisManagerEmail = "managers_primary_work_email"
+
+// ManagerEmailValue returns the primary email of the manager of p as
+// published by HRIS.
+func (p *Person) ManagerEmailValue() (string, bool) {
+	v, ok := groupValues(p, Hris)[hrisManagerEmail].(string)
+	return v, ok && v != ""
+}
+
+// identityAttributes lists the entries of the identities block by their
+// attribute name.
+var identityAttributes = []struct {
+	name  string
+	value func(*IdentitiesAttributesValuesArray) *StandardAttributeString
+}{
+	{"bugzilla_mozilla_org_id", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.BugzillaMozillaOrgID }},
+	{"bugzilla_mozilla_org_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString {
+		return i.BugzillaMozillaOrgPrimaryEmail
+	}},
+	{"custom_1_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.Custom1_PrimaryEmail }},
+	{"custom_2_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.Custom2_PrimaryEmail }},
+	{"custom_3_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.Custom3_PrimaryEmail }},
+	{"firefox_accounts_id", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.FirefoxAccountsID }},
+	{"firefox_accounts_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString {
+		return i.FirefoxAccountsPrimaryEmail
+	}},
+	{"github_id_v3", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.GithubIDV3 }},
+	{"github_id_v4", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.GithubIDV4 }},
+	{"github_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.GithubPrimaryEmail }},
+	{"google_oauth2_id", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.GoogleOauth2ID }},
+	{"google_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.GooglePrimaryEmail }},
+	{"mozilla_ldap_id", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.MozillaLDAPID }},
+	{"mozilla_ldap_primary_email", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.MozillaLDAPPrimaryEmail }},
+	{"mozilla_posix_id", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.MozillaPOSIXID }},
+	{"mozilliansorg_id", func(i *IdentitiesAttributesValuesArray) *StandardAttributeString { return i.MozilliansorgID }},
+}
+
+// identityValue returns an entry of the identities block, which may be
+// missing altogether or hold a null value.
+func identityValue(a *StandardAttributeString) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	return stringValue(*a)
+}
+
+// identity returns the entry of the identities block of p with the given
+// attribute name.
+func (p *Person) identity(name string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	for _, attr := range identityAttributes {
+		if attr.name == name {
+			return identityValue(attr.value(&p.Identities))
+		}
+	}
+	return "", false
+}
+
+func (p *Person) BugzillaID() (string, bool) {
+	return p.identity("bugzilla_mozilla_org_id")
+}
+
+func (p *Person) BugzillaPrimaryEmail() (string, bool) {
+	return p.identity("bugzilla_mozilla_org_primary_email")
+}
+
+func (p *Person) FirefoxAccountsID() (string, bool) {
+	return p.identity("firefox_accounts_id")
+}
+
+func (p *Person) FirefoxAccountsPrimaryEmail() (string, bool) {
+	return p.identity("firefox_accounts_primary_email")
+}
+
+// GithubIDV3 returns the numeric GitHub user id of p.
+func (p *Person) GithubIDV3() (string, bool) {
+	return p.identity("github_id_v3")
+}
+
+// GithubIDV4 returns the GitHub GraphQL node id of p.
+func (p *Person) GithubIDV4() (string, bool) {
+	return p.identity("github_id_v4")
+}
+
+func (p *Person) GithubPrimaryEmail() (string, bool) {
+	return p.identity("github_primary_email")
+}
+
+func (p *Person) GoogleOAuthID() (string, bool) {
+	return p.identity("google_oauth2_id")
+}
+
+func (p *Person) GooglePrimaryEmail() (string, bool) {
+	return p.identity("google_primary_email")
+}
+
+func (p *Person) MozillaLDAPID() (string, bool) {
+	return p.identity("mozilla_ldap_id")
+}
+
+func (p *Person) MozillaLDAPPrimaryEmail() (string, bool) {
+	return p.identity("mozilla_ldap_primary_email")
+}
+
+func (p *Person) MozillaPOSIXID() (string, bool) {
+	return p.identity("mozilla_posix_id")
+}
+
+func (p *Person) MozilliansorgID() (string, bool) {
+	return p.identity("mozilliansorg_id")
+}
+
+// IdentityMap returns the set entries of the identities block keyed by
+// their attribute name, such as "github_id_v4".
+func (p *Person) IdentityMap() map[string]string {
+	identities := map[string]string{}
+	if p == nil {
+		return identities
+	}
+	for _, attr := range identityAttributes {
+		if v, ok := identityValue(attr.value(&p.Identities)); ok {
+			identities[attr.name] = v
+		}
+	}
+	return identities
+}
+
+// AllEmails returns the primary email of p followed by the emails of its
+// identities, lowercased and without duplicates.
+func (p *Person) AllEmails() []string {
+	emails := []string{}
+	if p == nil {
+		return emails
+	}
+	seen := map[string]bool{}
+	add := func(email string) {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email != "" && !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+	add(p.PrimaryEmail.Value)
+	for _, attr := range identityAttributes {
+		if strings.HasSuffix(attr.name, "_primary_email") {
+			if v, ok := identityValue(attr.value(&p.Identities)); ok {
+				add(v)
+			}
+		}
+	}
+	return emails
+}
+
+// HasEmail reports whether addr is one of AllEmails, ignoring case.
+func (p *Person) HasEmail(addr string) bool {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	if addr == "" {
+		return false
+	}
+	for _, email := range p.AllEmails() {
+		if email == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether p is Mozilla staff. It is false for community
+// contributors, whose profiles carry no staff_information.
+func (p *Person) IsStaff() bool {
+	return p != nil && p.StaffInformation.Staff.Value
+}
+
+// ManagerStatus reports whether p manages other staff.
+func (p *Person) ManagerStatus() bool {
+	return p != nil && p.StaffInformation.Manager.Value
+}
+
+func (p *Person) CostCenter() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.StaffInformation.CostCenter)
+}
+
+func (p *Person) Team() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.StaffInformation.Team)
+}
+
+func (p *Person) WorkerType() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.StaffInformation.WorkerType)
+}
+
+func (p *Person) OfficeLocation() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	return stringValue(p.StaffInformation.OfficeLocation)
+}
@@ -0,0 +1,214 @@
+package person_api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// decodePerson decodes a fixture profile, where "null" is a nil person.
+func decodePerson(t *testing.T, profile string) *person_api.Person {
+	t.Helper()
+	var p *person_api.Person
+	if err := json.Unmarshal([]byte(profile), &p); err != nil {
+		t.Fatalf("fixture %s does not decode: %v", profile, err)
+	}
+	return p
+}
+
+func TestBooleanAccessors(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    string
+		wantValue  bool
+		wantExists bool
+	}{
+		{"nil person", `null`, false, false},
+		{"empty profile", `{}`, false, false},
+		{"null attribute", `{"active": null}`, false, false},
+		{"null value", `{"active": {"value": null}}`, false, false},
+		{"true", `{"active": {"value": true}}`, true, true},
+		{"false without metadata", `{"active": {"value": false}}`, false, false},
+		{"false with metadata", `{"active": {"metadata": {"verified": true}, "value": false}}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := decodePerson(t, tt.profile).ActiveValue()
+			if value != tt.wantValue || ok != tt.wantExists {
+				t.Errorf("ActiveValue() = %v, %v, want %v, %v", value, ok, tt.wantValue, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestListAccessors(t *testing.T) {
+	accessors := map[string]func(*person_api.Person) (map[string]string, bool){
+		"languages":       (*person_api.Person).LanguagesValues,
+		"phone_numbers":   (*person_api.Person).PhoneNumbersValues,
+		"tags":            (*person_api.Person).TagsValues,
+		"uris":            (*person_api.Person).UrisValues,
+		"usernames":       (*person_api.Person).UsernamesValues,
+		"ssh_public_keys": (*person_api.Person).SSHPublicKeysValues,
+		"pgp_public_keys": (*person_api.Person).PGPPublicKeysValues,
+	}
+	tests := []struct {
+		name       string
+		attribute  string
+		want       map[string]string
+		wantExists bool
+	}{
+		{"absent", ``, map[string]string{}, false},
+		{"null attribute", `null`, map[string]string{}, false},
+		{"no values", `{"metadata": {"verified": true}}`, map[string]string{}, false},
+		{"null values", `{"values": null}`, map[string]string{}, false},
+		{"empty values", `{"values": {}}`, map[string]string{}, false},
+		{"null value", `{"values": {"a": null}}`, map[string]string{"a": ""}, true},
+		{"values", `{"values": {"a": "1", "b": "2"}}`, map[string]string{"a": "1", "b": "2"}, true},
+	}
+	for field, accessor := range accessors {
+		for _, tt := range tests {
+			t.Run(field+"/"+tt.name, func(t *testing.T) {
+				profile := `{}`
+				if tt.attribute != "" {
+					profile = `{"` + field + `": ` + tt.attribute + `}`
+				}
+				got, ok := accessor(decodePerson(t, profile))
+				if !reflect.DeepEqual(got, tt.want) || ok != tt.wantExists {
+					t.Errorf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantExists)
+				}
+			})
+		}
+		t.Run(field+"/nil person", func(t *testing.T) {
+			got, ok := accessor(nil)
+			if len(got) != 0 || ok {
+				t.Errorf("got %v, %v, want an empty map and false", got, ok)
+			}
+		})
+	}
+}
+
+func TestStringAccessors(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    string
+		want       string
+		wantExists bool
+	}{
+		{"nil person", `null`, "", false},
+		{"empty profile", `{}`, "", false},
+		{"null value", `{"primary_email": {"value": null}}`, "", false},
+		{"empty value", `{"primary_email": {"value": ""}}`, "", false},
+		{"value", `{"primary_email": {"value": "user@mozilla.com"}}`, "user@mozilla.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodePerson(t, tt.profile).PrimaryEmailValue()
+			if got != tt.want || ok != tt.wantExists {
+				t.Errorf("PrimaryEmailValue() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestIdentityAccessors(t *testing.T) {
+	getters := []struct {
+		name  string
+		get   func(*person_api.Person) (string, bool)
+		field string
+	}{
+		{"BugzillaID", (*person_api.Person).BugzillaID, "bugzilla_mozilla_org_id"},
+		{"BugzillaPrimaryEmail", (*person_api.Person).BugzillaPrimaryEmail, "bugzilla_mozilla_org_primary_email"},
+		{"FirefoxAccountsID", (*person_api.Person).FirefoxAccountsID, "firefox_accounts_id"},
+		{"FirefoxAccountsPrimaryEmail", (*person_api.Person).FirefoxAccountsPrimaryEmail, "firefox_accounts_primary_email"},
+		{"GithubIDV3", (*person_api.Person).GithubIDV3, "github_id_v3"},
+		{"GithubIDV4", (*person_api.Person).GithubIDV4, "github_id_v4"},
+		{"GithubPrimaryEmail", (*person_api.Person).GithubPrimaryEmail, "github_primary_email"},
+		{"GoogleOAuthID", (*person_api.Person).GoogleOAuthID, "google_oauth2_id"},
+		{"GooglePrimaryEmail", (*person_api.Person).GooglePrimaryEmail, "google_primary_email"},
+		{"MozillaLDAPID", (*person_api.Person).MozillaLDAPID, "mozilla_ldap_id"},
+		{"MozillaLDAPPrimaryEmail", (*person_api.Person).MozillaLDAPPrimaryEmail, "mozilla_ldap_primary_email"},
+		{"MozillaPOSIXID", (*person_api.Person).MozillaPOSIXID, "mozilla_posix_id"},
+		{"MozilliansorgID", (*person_api.Person).MozilliansorgID, "mozilliansorg_id"},
+	}
+	for _, g := range getters {
+		tests := []struct {
+			name       string
+			profile    string
+			want       string
+			wantExists bool
+		}{
+			{"nil person", `null`, "", false},
+			{"empty profile", `{}`, "", false},
+			{"missing identities", `{"identities": {}}`, "", false},
+			{"null value", `{"identities": {"` + g.field + `": {"value": null}}}`, "", false},
+			{"value", `{"identities": {"` + g.field + `": {"value": "id"}}}`, "id", true},
+		}
+		for _, tt := range tests {
+			t.Run(g.name+"/"+tt.name, func(t *testing.T) {
+				got, ok := g.get(decodePerson(t, tt.profile))
+				if got != tt.want || ok != tt.wantExists {
+					t.Errorf("%s() = %q, %v, want %q, %v", g.name, got, ok, tt.want, tt.wantExists)
+				}
+			})
+		}
+	}
+}
+
+func TestStaffAccessors(t *testing.T) {
+	type staff struct {
+		IsStaff, ManagerStatus               bool
+		CostCenter, Team, WorkerType, Office string
+		HasCostCenter, HasTeam               bool
+		HasWorkerType, HasOffice             bool
+	}
+	fixture := func(path string) string {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	none := staff{}
+	tests := []struct {
+		name    string
+		profile string
+		want    staff
+	}{
+		{"nil person", `null`, none},
+		{"missing staff_information", `{}`, none},
+		{"null staff_information", `{"staff_information": null}`, none},
+		{"empty staff_information", `{"staff_information": {}}`, none},
+		{"null values", `{"staff_information": {"staff": {"value": null}, "manager": {"value": null}, "team": {"value": null}}}`, none},
+		{"contributor fixture", fixture("testdata/valid/contributor.json"), none},
+		{"staff fixture", fixture("testdata/valid/staff.json"), staff{
+			IsStaff:       true,
+			ManagerStatus: true,
+			CostCenter:    "1420 - Enterprise Information Security",
+			Team:          "Security Operations",
+			WorkerType:    "Employee",
+			Office:        "Berlin",
+			HasCostCenter: true,
+			HasTeam:       true,
+			HasWorkerType: true,
+			HasOffice:     true,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := decodePerson(t, tt.profile)
+			var got staff
+			got.IsStaff = p.IsStaff()
+			got.ManagerStatus = p.ManagerStatus()
+			got.CostCenter, got.HasCostCenter = p.CostCenter()
+			got.Team, got.HasTeam = p.Team()
+			got.WorkerType, got.HasWorkerType = p.WorkerType()
+			got.Office, got.HasOffice = p.OfficeLocation()
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
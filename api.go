@@ -2,51 +2,348 @@ package person_api
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/oauth2"
 )
 
+// PersonAPI is the read API of Client, for consumers that want to substitute
+// it in tests, for instance with personapitest.MockClient.
+type PersonAPI interface {
+	GetPersonByEmail(ctx context.Context, primaryEmail string) (*Person, error)
+	GetPersonByUserId(ctx context.Context, userid string) (*Person, error)
+	GetPersonByUUID(ctx context.Context, uuid string) (*Person, error)
+	GetPersonByUsername(ctx context.Context, primaryUsername string) (*Person, error)
+	GetAllUsers(ctx context.Context) ([]*Person, error)
+	GetPersonsInGroups(ctx context.Context, groups []string) ([]*Person, error)
+}
+
+var _ PersonAPI = (*Client)(nil)
+
 type Client struct {
+	// throttledRetries is accessed atomically. It is allocated on its own,
+	// which keeps it 64-bit aligned on 32-bit platforms and lets
+	// Client.WithScopes copy the client.
+	throttledRetries *int64
+
 	clientId     string
 	clientSecret string
 	accessToken  string
 	httpClient   *http.Client
 	baseUrl      string
 	authUrl      string
+	audience     string
+	scope        string
+	userAgent    string
+	// userAgentSuffix is appended to userAgent once all options have been
+	// applied, see WithUserAgentSuffix.
+	userAgentSuffix string
+	// defaultHeaders are set on every request, see WithDefaultHeader.
+	defaultHeaders http.Header
+	timeout        time.Duration
+	expiryMargin   time.Duration
+	enumTimeout    time.Duration
+	maxPages       int
+	maxUsers       int
+	// progress is called by pageGuard after every page, see WithProgress.
+	progress    func(fetchedUsers, pages int)
+	retryPolicy RetryPolicy
+	// tokenSource, if set, supplies the token of every request instead of
+	// accessToken.
+	tokenSource oauth2.TokenSource
+	// credentials, if set, supplies the client id and secret of each token
+	// request instead of clientId and clientSecret.
+	credentials CredentialsFunc
+	tokenCache  TokenCache
+	// responseCache, if set, revalidates person lookups with their ETag.
+	responseCache ResponseCache
+	personCache   *personCache
+	// negativeTTL is applied to personCache once all options have been
+	// applied, see WithNegativePersonCache.
+	negativeTTL time.Duration
+	limiter     *rateLimiter
+	breaker     *circuitBreaker
+	circuitHook func(from, to CircuitState)
+	stats       StatsRecorder
+	middlewares []func(http.RoundTripper) http.RoundTripper
+	// transport is the chain built by buildTransport from the options.
+	transport http.RoundTripper
+	tracer    Tracer
+	logger    Logger
+	// debugWriter receives the requests and responses dumped by
+	// debugTransport.
+	debugWriter    io.Writer
+	debugBodyLimit int
+	// redactors are applied to captured bodies after DefaultRedactor, see
+	// WithRedactor.
+	redactors []Redactor
+	// autoRefresh is the margin of the background refresher, which runs
+	// until stop is closed and then closes stopped.
+	autoRefresh time.Duration
+	stop        chan struct{}
+	stopped     chan struct{}
+	closeOnce   *sync.Once
+	// verifyKeys enables signature verification of looked up profiles.
+	verifyKeys PublisherKeys
+	// strictDecoding rejects profiles with unknown fields, see
+	// WithStrictDecoding.
+	strictDecoding bool
+
+	// transportOptions customize the transport of httpClient, see
+	// configureHTTPTransport.
+	transportOptions []httpTransportOption
+	// disableHTTP2 is set by TransportTuning.DisableHTTP2 and applied after
+	// all transportOptions.
+	disableHTTP2 bool
+
+	// parent is the client this one was derived from with
+	// Client.WithScopes. derivedMu guards derived, the clients derived from
+	// this one, and closed.
+	parent    *Client
+	derivedMu *sync.Mutex
+	derived   map[*Client]bool
+	closed    bool
+
+	// rwLock guards accessToken, tokenExpiresAt, tokenInfo, lastSecret,
+	// the secret returned by credentials for the latest token request, and
+	// staleToken and refreshFailedAt, the token whose refresh within the
+	// expiry margin last failed and when, only and is never held across a
+	// request. refreshMu guards refreshing, the refresh in flight.
+	tokenExpiresAt  time.Time
+	lastSecret      string
+	staleToken      string
+	refreshFailedAt time.Time
+	tokenInfo       TokenInfo
+	rwLock          *sync.RWMutex
+	refreshMu       *sync.Mutex
+	refreshing      *refreshCall
+}
 
-	rwLock *sync.RWMutex
+func NewClient(id, secret string, opts ...Option) (*Client, error) {
+	c, err := newClient(id, secret, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !c.loadCachedToken() {
+		if err := c.RefreshAccessToken(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	c.startAutoRefresh()
+	return c, nil
 }
 
-func NewClient(id, secret, baseUrl, authUrl string) (*Client, error) {
-	httpClient := &http.Client{}
-	c := &Client{
-		httpClient:   httpClient,
-		clientId:     id,
-		clientSecret: secret,
-		baseUrl:      baseUrl,
-		authUrl:      authUrl,
-		rwLock:       &sync.RWMutex{},
-	}
-	err := c.RefreshAccessToken()
+// NewClientWithToken creates a client that uses an access token obtained
+// elsewhere and holds no client credentials, so no auth request is made.
+// Refreshing the token of such a client fails with ErrNoCredentials; use
+// SetAccessToken to rotate it.
+func NewClientWithToken(token, baseUrl string, opts ...Option) (*Client, error) {
+	if token == "" {
+		return nil, fmt.Errorf("Access token must not be empty")
+	}
+	c, err := newClient("", "", append([]Option{WithBaseURL(baseUrl)}, opts...))
 	if err != nil {
 		return nil, err
 	}
+	if c.autoRefresh > 0 {
+		return nil, fmt.Errorf("Auto refresh needs client credentials")
+	}
+	c.accessToken = token
 	return c, nil
 }
 
-type getMethod int
+// newClient applies opts to a client with the defaults, without fetching a
+// token.
+func newClient(id, secret string, opts []Option) (*Client, error) {
+	c := &Client{
+		httpClient:       &http.Client{Timeout: DefaultTimeout},
+		clientId:         id,
+		clientSecret:     secret,
+		baseUrl:          DefaultBaseURL,
+		authUrl:          DefaultAuthURL,
+		audience:         DefaultAudience,
+		scope:            DefaultScopes.String(),
+		userAgent:        DefaultUserAgent,
+		expiryMargin:     DefaultExpiryMargin,
+		enumTimeout:      DefaultEnumerationTimeout,
+		maxPages:         DefaultMaxPages,
+		maxUsers:         DefaultMaxUsers,
+		retryPolicy:      DefaultRetryPolicy,
+		logger:           nopLogger{},
+		debugBodyLimit:   DefaultDebugBodyLimit,
+		throttledRetries: new(int64),
+		closeOnce:        &sync.Once{},
+		derivedMu:        &sync.Mutex{},
+		rwLock:           &sync.RWMutex{},
+		refreshMu:        &sync.Mutex{},
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	c.userAgent += c.userAgentSuffix
+	if c.negativeTTL > 0 {
+		if c.personCache == nil {
+			return nil, fmt.Errorf("Negative person caching requires WithPersonCache")
+		}
+		c.personCache.negativeTTL = c.negativeTTL
+	}
+	if err := c.configureHTTPTransport(); err != nil {
+		return nil, err
+	}
+	if c.timeout > 0 {
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
+	}
+	if c.breaker != nil {
+		c.breaker.onChange = c.circuitHook
+		if recorder, ok := c.stats.(CircuitStateRecorder); ok {
+			hook := c.circuitHook
+			c.breaker.onChange = func(from, to CircuitState) {
+				recorder.ObserveCircuitState(from, to)
+				if hook != nil {
+					hook(from, to)
+				}
+			}
+		}
+	}
+	c.transport = c.buildTransport()
+	return c, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	c.applyHeaders(ctx, req)
+	c.injectTraceHeaders(ctx, req)
+	return req, nil
+}
+
+// do sends req through the transport chain, see buildTransport.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	return c.transport.RoundTrip(req)
+}
+
+// enumerationContext bounds an enumeration spanning many requests by the
+// enumeration timeout, on top of the per-request timeout of the HTTP client.
+func (c *Client) enumerationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.enumTimeout)
+}
+
+// readAndClose reads the whole body of resp and closes it, so that pages
+// of a pagination loop do not keep connections checked out.
+func readAndClose(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	body, err := decodedBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(body)
+}
+
+// decodeAndClose decodes the JSON body of resp into v and closes the body.
+// Anything after the JSON value is drained to keep the connection reusable.
+// The decoder still buffers the whole value; see decodeUsersPage for pages
+// too large for that.
+func decodeAndClose(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	body, err := decodedBody(resp)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return err
+	}
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	return err
+}
+
+// decodedBody returns the body of resp, decompressing it if it is gzipped.
+// The transport only does so itself unless Accept-Encoding was set
+// explicitly, see acceptGzip, or compression is disabled.
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") || resp.Uncompressed {
+		return resp.Body, nil
+	}
+	return gzip.NewReader(resp.Body)
+}
+
+// acceptGzip requests compressed responses for the large enumeration
+// pages, also when the HTTP client's transport has compression disabled.
+// CIS profiles are repetitive JSON and compress well.
+func acceptGzip(ctx context.Context) context.Context {
+	return WithHeader(ctx, "Accept-Encoding", "gzip")
+}
+
+// LookupField is the field a single person is looked up by, see
+// GetPersonBy.
+type LookupField int
 
 const (
-	USERID           getMethod = 0
-	UUID             getMethod = 1
-	PRIMARY_EMAIL    getMethod = 2
-	PRIMARY_USERNAME getMethod = 3
+	USERID           LookupField = 0
+	UUID             LookupField = 1
+	PRIMARY_EMAIL    LookupField = 2
+	PRIMARY_USERNAME LookupField = 3
 )
 
+var lookupFieldNames = map[LookupField]string{
+	USERID:           "user_id",
+	UUID:             "uuid",
+	PRIMARY_EMAIL:    "primary_email",
+	PRIMARY_USERNAME: "primary_username",
+}
+
+// String returns the profile attribute name of f, such as "primary_email",
+// which ParseLookupField accepts.
+func (f LookupField) String() string {
+	if name, ok := lookupFieldNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("LookupField(%d)", int(f))
+}
+
+// ParseLookupField returns the field named s, as returned by
+// LookupField.String. Unknown names fail with an *UnknownLookupFieldError.
+func ParseLookupField(s string) (LookupField, error) {
+	for f, name := range lookupFieldNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	return 0, &UnknownLookupFieldError{Field: s}
+}
+
+// operation names the lookup in traces.
+func (m LookupField) operation() string {
+	switch m {
+	case USERID:
+		return "GetPersonByUserId"
+	case UUID:
+		return "GetPersonByUUID"
+	case PRIMARY_EMAIL:
+		return "GetPersonByEmail"
+	case PRIMARY_USERNAME:
+		return "GetPersonByUsername"
+	}
+	return "GetPerson"
+}
+
 type listMethod int
 
 const (
@@ -54,65 +351,282 @@ const (
 	GET_ALL_ACTIVE_STAFF listMethod = 1
 )
 
-func (c *Client) RefreshAccessToken() error {
-	c.rwLock.Lock()
-	defer c.rwLock.Unlock()
-	accessToken, err := c.GetAccessToken(c.authUrl)
+// RefreshAccessToken requests a new access token. Concurrent calls, and the
+// refreshes triggered by requests, share a single auth request and its
+// result.
+func (c *Client) RefreshAccessToken(ctx context.Context) error {
+	return c.refresh(ctx)
+}
+
+// refreshCall is an auth request shared by everyone waiting for a token.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// refresh joins the refresh in flight or starts one. The auth request runs
+// detached from ctx, so a caller giving up does not fail the others; it is
+// bounded by the HTTP client timeout and the retry policy.
+func (c *Client) refresh(ctx context.Context) error {
+	return c.refreshUnless(ctx, nil)
+}
+
+// refreshUnless is refresh, except that no refresh is started if done
+// reports, under refreshMu, that it is no longer needed.
+func (c *Client) refreshUnless(ctx context.Context, done func() bool) error {
+	c.refreshMu.Lock()
+	call := c.refreshing
+	if call == nil {
+		if done != nil && done() {
+			c.refreshMu.Unlock()
+			return nil
+		}
+		call = &refreshCall{done: make(chan struct{})}
+		c.refreshing = call
+		go func() {
+			call.err = c.fetchToken(context.Background())
+			c.refreshMu.Lock()
+			c.refreshing = nil
+			c.refreshMu.Unlock()
+			close(call.done)
+		}()
+	}
+	c.refreshMu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetchToken requests a token and stores it; use refresh instead.
+func (c *Client) fetchToken(ctx context.Context) error {
+	start := time.Now()
+	authResp, err := c.requestToken(ctx, c.authUrl)
+	if c.stats != nil {
+		c.stats.ObserveTokenRefresh(time.Since(start), err)
+	}
 	if err != nil {
+		c.logger.Error("Refreshing the access token failed", "error", err)
 		return err
 	}
-	c.accessToken = accessToken
+	c.rwLock.Lock()
+	c.storeToken(authResp)
+	token, expiresAt, info := c.accessToken, c.tokenExpiresAt, c.tokenInfo
+	c.rwLock.Unlock()
+	c.storeCachedToken(token, expiresAt, info)
+	c.logger.Info("Refreshed the access token", "expires_at", expiresAt, "scope", info.Scope)
 	return nil
 }
 
-func (c *Client) GetAccessToken(authUrl string) (string, error) {
-	// TODO: Support passing in audience, scope, etc.
+// ThrottledRetries returns how many requests have been retried after a 429
+// response since the client was created.
+func (c *Client) ThrottledRetries() int64 {
+	return atomic.LoadInt64(c.throttledRetries)
+}
+
+// TokenExpiresAt returns when the current access token expires, or the zero
+// time if the auth server did not report a lifetime.
+func (c *Client) TokenExpiresAt() time.Time {
+	c.rwLock.RLock()
+	defer c.rwLock.RUnlock()
+	return c.tokenExpiresAt
+}
+
+// SetAccessToken replaces the access token, for callers rotating tokens
+// themselves. The token is assumed not to expire until replaced again.
+func (c *Client) SetAccessToken(token string) {
+	c.rwLock.Lock()
+	defer c.rwLock.Unlock()
+	c.accessToken = token
+	c.tokenExpiresAt = time.Time{}
+	c.tokenInfo = TokenInfo{}
+}
+
+// hasCredentials is false for clients created with NewClientWithToken.
+func (c *Client) hasCredentials() bool {
+	return c.clientId != "" || c.clientSecret != "" || c.credentials != nil
+}
+
+// storeToken must be called with rwLock held for writing.
+func (c *Client) storeToken(authResp *AuthResp) {
+	c.accessToken = authResp.AccessToken
+	c.tokenInfo = TokenInfo{Scope: authResp.Scope, TokenType: authResp.TokenType}
+	c.tokenExpiresAt = time.Time{}
+	if authResp.ExpiresIn > 0 {
+		c.tokenExpiresAt = time.Now().Add(time.Duration(authResp.ExpiresIn) * time.Second)
+	}
+}
+
+// getToken returns the current access token. The lock only guards the
+// field itself; callers must not hold it across requests.
+func (c *Client) getToken() string {
+	c.rwLock.RLock()
+	defer c.rwLock.RUnlock()
+	return c.accessToken
+}
+
+// refreshFailureBackoff is how long freshToken keeps using a token within
+// the expiry margin without refreshing it after a refresh failed.
+const refreshFailureBackoff = 10 * time.Second
+
+// freshToken returns an access token that is not within the expiry margin,
+// refreshing it first if necessary. If the refresh fails, a token that has
+// not expired yet is still returned, and it is not refreshed again for
+// refreshFailureBackoff so that requests do not each wait for a failing
+// token request.
+func (c *Client) freshToken(ctx context.Context) (string, error) {
+	if c.tokenSource != nil {
+		return c.sourceToken()
+	}
+	c.rwLock.RLock()
+	token, expiresAt := c.accessToken, c.tokenExpiresAt
+	backingOff := c.staleToken == token && time.Since(c.refreshFailedAt) < refreshFailureBackoff
+	c.rwLock.RUnlock()
+
+	now := time.Now()
+	if expiresAt.IsZero() || now.Add(c.expiryMargin).Before(expiresAt) {
+		return token, nil
+	}
+	if backingOff && now.Before(expiresAt) {
+		return token, nil
+	}
+	if err := c.refreshStaleToken(ctx, token); err != nil {
+		// Within the margin the token still works, so a failed refresh,
+		// e.g. of rotated credentials, is only fatal once it expires.
+		if ctx.Err() == nil && time.Now().Before(expiresAt) {
+			c.rwLock.Lock()
+			c.staleToken, c.refreshFailedAt = token, time.Now()
+			c.rwLock.Unlock()
+			c.logger.Warn("Using the current access token after a failed refresh", "error", err, "expires_at", expiresAt, "retry_in", refreshFailureBackoff)
+			return token, nil
+		}
+		return "", err
+	}
+	return c.getToken(), nil
+}
+
+// refreshStaleToken refreshes the access token unless another request
+// already replaced the stale token in the meantime, so that a burst of 401
+// responses results in a single refresh. The token is compared under
+// refreshMu: a refresh still in flight is joined, and one that has finished
+// has stored its token by then.
+func (c *Client) refreshStaleToken(ctx context.Context, stale string) error {
+	return c.refreshUnless(ctx, func() bool { return c.getToken() != stale })
+}
+
+// getAuthenticated issues an authenticated GET, see requestAuthenticated.
+func (c *Client) getAuthenticated(ctx context.Context, rawUrl string) (*http.Response, error) {
+	return c.requestAuthenticated(ctx, "GET", rawUrl)
+}
+
+// requestAuthenticated wraps sendAuthenticated in a request span.
+func (c *Client) requestAuthenticated(ctx context.Context, method, rawUrl string) (*http.Response, error) {
+	ctx, span := c.startSpan(ctx, requestSpanName)
+	var endpoint string
+	if u, err := url.Parse(rawUrl); err == nil {
+		endpoint = c.endpointLabel(u)
+		span.SetAttribute(AttrEndpoint, endpoint)
+	}
+	resp, err := c.sendAuthenticated(ctx, method, rawUrl)
+	spanErr := err
+	if err == nil {
+		span.SetAttribute(AttrStatusCode, resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			spanErr = fmt.Errorf("Persons API responded with status code %d", resp.StatusCode)
+			c.logger.Warn("Person API request failed", "endpoint", endpoint, "status", resp.StatusCode)
+		}
+	}
+	span.End(spanErr)
+	return resp, err
+}
+
+// sendAuthenticated issues a request with the access token, which the
+// transport chain refreshes once on a 401. A 401 that remains is reported as
+// an *UnauthorizedError, a 403 as a *ForbiddenError.
+func (c *Client) sendAuthenticated(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, &UnauthorizedError{APIError: c.newAPIError(resp)}
+	case http.StatusForbidden:
+		return nil, &ForbiddenError{APIError: c.newAPIError(resp)}
+	}
+	return resp, nil
+}
+
+func (c *Client) GetAccessToken(ctx context.Context, authUrl string) (string, error) {
+	authResp, err := c.requestToken(ctx, authUrl)
+	if err != nil {
+		return "", err
+	}
+	return authResp.AccessToken, nil
+}
+
+func (c *Client) requestToken(ctx context.Context, authUrl string) (*AuthResp, error) {
+	if !c.hasCredentials() {
+		return nil, ErrNoCredentials
+	}
+	clientId, clientSecret, err := c.clientCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.credentials != nil {
+		c.rwLock.Lock()
+		c.lastSecret = clientSecret
+		c.rwLock.Unlock()
+	}
 	authReqBody, err := json.Marshal(AuthReq{
-		Audience:     "api.sso.mozilla.com",
-		Scope:        "classification:public display:public search:all",
+		Audience:     c.audience,
+		Scope:        c.scope,
 		GrantType:    "client_credentials",
-		ClientId:     c.clientId,
-		ClientSecret: c.clientSecret})
+		ClientId:     clientId,
+		ClientSecret: clientSecret})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	resp, err := c.httpClient.Post(authUrl, "application/json", bytes.NewBuffer(authReqBody))
+	req, err := c.newRequest(withTokenRequest(ctx), "POST", authUrl, bytes.NewBuffer(authReqBody))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
 	}
 
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("Persons API responded with status code %d", resp.StatusCode)
+		return nil, c.newAuthError(resp)
 	}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readAndClose(resp)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var authResp AuthResp
 	err = json.Unmarshal(body, &authResp)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return authResp.AccessToken, nil
-}
-
-type getAllUsersResp struct {
-	Items    []*Person `json:"Items"`
-	NextPage *nextPage `json:"nextPage"`
-}
-
-type nextPage struct {
-	Id string `json:"id"`
+	return &authResp, nil
 }
 
 type getAllActiveStaffResp struct {
 	Users    []byAttrUserResp `json:"users"`
-	NextPage string           `json:"nextPage"`
+	NextPage rawCursor        `json:"nextPage"`
 }
 
 type byAttrUserResp struct {
@@ -120,221 +634,205 @@ type byAttrUserResp struct {
 	Profile *Person                 `json:"profile"`
 }
 
-func (c *Client) GetAllActiveStaff() ([]*Person, error) {
+func (c *Client) GetAllActiveStaff(ctx context.Context) ([]*Person, error) {
+	attrs := url.Values{}
+	attrs.Set("staff_information.staff", "True")
+	return c.getByAttribute(ctx, attrs)
+}
+
+// getByAttribute lists the full profiles of the active users whose
+// attributes contain the given values, using
+// /v2/users/id/all/by_attribute_contains.
+func (c *Client) getByAttribute(ctx context.Context, attrs url.Values) (persons []*Person, err error) {
+	ctx, cancel := c.enumerationContext(ctx)
+	defer cancel()
+	ctx, span := c.startSpan(ctx, "ListByAttribute")
+	defer func() { span.End(err) }()
+
 	var (
 		allUsers []*Person
 		nextPage string
-		req      *http.Request
+		guard    = c.newPageGuard("")
 	)
 
-	c.rwLock.RLock()
-	defer c.rwLock.RUnlock()
-
-	getAllUrl, err := url.Parse(c.baseUrl + "/v2/users/id/all/by_attribute_contains")
+	getAllUrl, err := url.Parse(joinPath(c.baseUrl, "/v2/users/id/all/by_attribute_contains"))
 	if err != nil {
 		return nil, err
 	}
-	q := getAllUrl.Query()
+	q := url.Values{}
+	for k, v := range attrs {
+		q[k] = v
+	}
 	q.Set("active", "True")
 	q.Set("fullProfiles", "True")
-	q.Set("staff_information.staff", "True")
 	getAllUrl.RawQuery = q.Encode()
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		if nextPage != "" {
 			q := getAllUrl.Query()
 			q.Set("nextPage", nextPage)
 			getAllUrl.RawQuery = q.Encode()
 		}
 
-		req, err = http.NewRequest("GET", getAllUrl.String(), nil)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Add("Authorization", "Bearer "+c.accessToken)
-
-		resp, err := c.httpClient.Do(req)
+		resp, err := c.getAuthenticated(acceptGzip(ctx), getAllUrl.String())
 		if err != nil {
 			return nil, err
 		}
 
 		if resp.StatusCode >= 400 {
-			return nil, fmt.Errorf("Persons API responded with status code %d", resp.StatusCode)
+			return nil, c.newAPIError(resp)
 		}
 
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
+		var uResp getAllActiveStaffResp
+		if err := decodeAndClose(resp, &uResp); err != nil {
 			return nil, err
 		}
 
-		var uResp getAllActiveStaffResp
-		err = json.Unmarshal(body, &uResp)
-		if err != nil {
+		if err := guard.next(len(uResp.Users), string(uResp.NextPage)); err != nil {
 			return nil, err
 		}
-
 		for _, i := range uResp.Users {
-			allUsers = append(allUsers, i.Profile)
+			// Without fullProfiles being honored there is no profile.
+			if i.Profile != nil {
+				allUsers = append(allUsers, i.Profile)
+			}
 		}
+		c.logger.Debug("Fetched a page of users", "endpoint", "/v2/users/id/all/by_attribute_contains",
+			"count", len(uResp.Users), "cursor", nextPage)
 
 		if uResp.NextPage == "" {
 			break
 		}
-		nextPage = uResp.NextPage
+		nextPage = string(uResp.NextPage)
 	}
 
 	return allUsers, nil
 }
 
-func (c *Client) GetAllUsers() ([]*Person, error) {
-	var (
-		allUsers []*Person
-		next     *nextPage
-		req      *http.Request
-	)
-
-	c.rwLock.RLock()
-	defer c.rwLock.RUnlock()
+func (c *Client) getPerson(ctx context.Context, method LookupField, id string) (person *Person, err error) {
+	ctx, span := c.startSpan(ctx, method.operation())
+	defer func() { span.End(err) }()
 
-	getAllUrl, err := url.Parse(c.baseUrl + "/v2/users")
+	personUrl, err := c.personURL(method, id)
 	if err != nil {
 		return nil, err
 	}
 
-	for {
-		if next != nil && next.Id != "" {
-			q := getAllUrl.Query()
-			q.Set("nextPage", fmt.Sprintf("{\"id\":\"%s\"}", next.Id))
-			getAllUrl.RawQuery = q.Encode()
-		}
-
-		req, err = http.NewRequest("GET", getAllUrl.String(), nil)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Add("Authorization", "Bearer "+c.accessToken)
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
+	personKey := personCacheKey(method, id)
+	if p, ok, err := c.cachedPerson(ctx, personKey); ok {
+		return p, err
+	}
+	var body []byte
+	defer func() { c.storePerson(personKey, body, err) }()
 
-		if resp.StatusCode >= 400 {
-			return nil, fmt.Errorf("Persons API responded with status code %d", resp.StatusCode)
-		}
+	body, err = c.fetchPerson(ctx, personUrl)
+	if err != nil {
+		return nil, err
+	}
 
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
+	p, err := c.unmarshalPerson(body)
+	if err != nil {
+		return nil, err
+	}
+	if isHollow(&p) {
+		return nil, ErrNotFound
+	}
 
-		var uResp getAllUsersResp
-		err = json.Unmarshal(body, &uResp)
-		if err != nil {
+	if c.verifyKeys != nil {
+		if err := p.VerifySignatures(c.verifyKeys); err != nil {
 			return nil, err
 		}
-
-		for _, i := range uResp.Items {
-			allUsers = append(allUsers, i)
-		}
-
-		if uResp.NextPage == nil {
-			break
-		}
-		next = uResp.NextPage
 	}
 
-	return allUsers, nil
+	return &p, nil
 }
 
-func (c *Client) getPerson(method getMethod, id string) (*Person, error) {
-	url := c.baseUrl + "/v2/user"
-
-	if method == USERID {
-		url = url + "/user_id/" + id
-	} else if method == UUID {
-		url = url + "/uuid/" + id
-	} else if method == PRIMARY_EMAIL {
-		url = url + "/primary_email/" + id
-	} else if method == PRIMARY_USERNAME {
-		url = url + "/primary_username/" + id
-	} else {
-		return nil, fmt.Errorf("Unknown method type")
+// personURL returns the URL looking up id by method.
+func (c *Client) personURL(method LookupField, id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("Cannot look up a person by an empty identifier")
+	}
+	field, ok := lookupFieldNames[method]
+	if !ok {
+		return "", &UnknownLookupFieldError{Field: method.String()}
 	}
+	return joinPath(c.baseUrl, "/v2/user", field, escapePathSegment(id)), nil
+}
 
-	c.rwLock.RLock()
-	defer c.rwLock.RUnlock()
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// fetchPerson returns the profile at personUrl, revalidating the copy in the
+// response cache if there is one. Error statuses are returned as built by
+// newAPIError, so a 404 satisfies errors.Is(err, ErrNotFound).
+func (c *Client) fetchPerson(ctx context.Context, personUrl string) ([]byte, error) {
+	cacheKey := c.responseCacheKey(ctx, personUrl)
+	cached := c.cachedResponse(cacheKey)
+	if cached != nil {
+		ctx = WithHeader(ctx, "If-None-Match", cached.ETag)
 	}
-	req.Header.Add("Authorization", "Bearer "+c.accessToken)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.getAuthenticated(ctx, personUrl)
 	if err != nil {
 		return nil, err
 	}
 
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return cached.Body, nil
+	}
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("Persons API responded with status code %d", resp.StatusCode)
+		return nil, c.newAPIError(resp)
 	}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readAndClose(resp)
 	if err != nil {
 		return nil, err
 	}
+	c.storeResponse(cacheKey, resp, body, cached)
+	return body, nil
+}
 
-	p, err := UnmarshalPerson(body)
-	if err != nil {
-		return nil, err
+// unmarshalPerson decodes a profile the way the client is configured to.
+func (c *Client) unmarshalPerson(data []byte) (Person, error) {
+	if c.strictDecoding {
+		return UnmarshalPersonStrict(data)
 	}
-
-	return &p, nil
+	return UnmarshalPerson(data)
 }
 
-func (c *Client) GetPersonByUserId(userid string) (*Person, error) {
-	return c.getPerson(USERID, userid)
-}
-func (c *Client) GetPersonByUUID(uuid string) (*Person, error) {
-	return c.getPerson(UUID, uuid)
-}
-func (c *Client) GetPersonByEmail(primaryEmail string) (*Person, error) {
-	return c.getPerson(PRIMARY_EMAIL, primaryEmail)
+// escapePathSegment escapes id for use as a single path segment. Plus signs
+// are escaped as well since the API decodes them as spaces.
+func escapePathSegment(id string) string {
+	return strings.Replace(url.PathEscape(id), "+", "%2B", -1)
 }
 
-func (c *Client) GetPersonByUsername(primaryUsername string) (*Person, error) {
-	return c.getPerson(PRIMARY_USERNAME, primaryUsername)
+// isHollow reports whether p has none of the identifiers of a profile. The
+// API answers some unknown identifiers with a 200 and "{}" or a profile
+// whose attributes are all null.
+func isHollow(p *Person) bool {
+	return p.UserID.Value == "" && p.UUID.Value == "" && p.PrimaryEmail.Value == ""
 }
 
-func (c *Client) GetPersonsInGroups(groups []string) ([]*Person, error) {
-	collectedPersons := []*Person{}
-	persons, err := c.GetAllActiveStaff()
-	if err != nil {
-		return collectedPersons, err
-	}
-	for _, person := range persons {
-		done := false
-		for group := range person.AccessInformation.LDAP.Values {
-			for _, specifiedGroup := range groups {
-				if group == specifiedGroup {
-					collectedPersons = append(collectedPersons, person)
-					done = true
-				}
+// GetPersonBy looks up a person by field, for callers choosing the field at
+// runtime. It is equivalent to the GetPersonBy... method of that field.
+func (c *Client) GetPersonBy(ctx context.Context, field LookupField, id string) (*Person, error) {
+	return c.getPerson(ctx, field, id)
+}
 
-				if done {
-					break
-				}
-			}
+func (c *Client) GetPersonByUserId(ctx context.Context, userid string) (*Person, error) {
+	return c.getPerson(ctx, USERID, userid)
+}
+func (c *Client) GetPersonByUUID(ctx context.Context, uuid string) (*Person, error) {
+	return c.getPerson(ctx, UUID, uuid)
+}
+func (c *Client) GetPersonByEmail(ctx context.Context, primaryEmail string) (*Person, error) {
+	return c.getPerson(ctx, PRIMARY_EMAIL, primaryEmail)
+}
 
-			if done {
-				break
-			}
-		}
-	}
-	return collectedPersons, nil
+func (c *Client) GetPersonByUsername(ctx context.Context, primaryUsername string) (*Person, error) {
+	return c.getPerson(ctx, PRIMARY_USERNAME, primaryUsername)
 }
 
 type AuthReq struct {
@@ -0,0 +1,100 @@
+package person_api
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// autoRefreshMinBackoff and autoRefreshMaxBackoff bound the wait
+	// between failed background refreshes.
+	autoRefreshMinBackoff = time.Second
+	autoRefreshMaxBackoff = time.Minute
+)
+
+// startAutoRefresh starts the background refresher if WithAutoRefresh was
+// given.
+func (c *Client) startAutoRefresh() {
+	if c.autoRefresh <= 0 {
+		return
+	}
+	c.stop = make(chan struct{})
+	c.stopped = make(chan struct{})
+	go c.autoRefreshLoop()
+}
+
+// autoRefreshLoop refreshes the token autoRefresh before it expires. Failed
+// refreshes are retried with a capped exponential backoff while the old
+// token keeps being used; requests refresh on their own once it is close to
+// expiring.
+func (c *Client) autoRefreshLoop() {
+	defer close(c.stopped)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-c.stop
+		cancel()
+	}()
+
+	var backoff time.Duration
+	for {
+		wait := backoff
+		if backoff == 0 {
+			expiresAt := c.TokenExpiresAt()
+			if expiresAt.IsZero() {
+				// Without a known lifetime there is nothing to schedule.
+				return
+			}
+			wait = time.Until(expiresAt.Add(-c.autoRefresh))
+			// Tokens living shorter than the margin must not spin.
+			if wait < autoRefreshMinBackoff {
+				wait = autoRefreshMinBackoff
+			}
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return
+		}
+
+		if err := c.refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = nextAutoRefreshBackoff(backoff)
+			continue
+		}
+		backoff = 0
+	}
+}
+
+func nextAutoRefreshBackoff(backoff time.Duration) time.Duration {
+	if backoff == 0 {
+		return autoRefreshMinBackoff
+	}
+	if backoff *= 2; backoff > autoRefreshMaxBackoff {
+		return autoRefreshMaxBackoff
+	}
+	return backoff
+}
+
+// Close stops the background refresher started by WithAutoRefresh and
+// closes idle connections, after closing the clients derived from c with
+// Client.WithScopes. The client must not be used afterwards. Close always
+// returns nil and may be called more than once.
+//
+// Closing a derived client only stops its own refresher, since it shares
+// the connections of its parent.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeDerived()
+		if c.stop != nil {
+			close(c.stop)
+			<-c.stopped
+		}
+		if c.parent != nil {
+			c.parent.forgetDerived(c)
+			return
+		}
+		c.httpClient.CloseIdleConnections()
+	})
+	return nil
+}
@@ -0,0 +1,77 @@
+package person_api
+
+import (
+	"context"
+	"sync"
+)
+
+// GetPersonsByEmails looks up many persons concurrently, using at most
+// concurrency requests at a time. Results and failures are reported per
+// email, so that for example an unknown email (ErrNotFound) does not affect
+// the others. Emails not looked up because ctx was done map to ctx.Err().
+func (c *Client) GetPersonsByEmails(ctx context.Context, emails []string, concurrency int) (map[string]*Person, map[string]error) {
+	return c.getPersons(ctx, PRIMARY_EMAIL, emails, concurrency)
+}
+
+// GetPersonsByUserIds is GetPersonsByEmails for user_ids.
+func (c *Client) GetPersonsByUserIds(ctx context.Context, userids []string, concurrency int) (map[string]*Person, map[string]error) {
+	return c.getPersons(ctx, USERID, userids, concurrency)
+}
+
+// GetPersonsByUUIDs is GetPersonsByEmails for uuids.
+func (c *Client) GetPersonsByUUIDs(ctx context.Context, uuids []string, concurrency int) (map[string]*Person, map[string]error) {
+	return c.getPersons(ctx, UUID, uuids, concurrency)
+}
+
+func (c *Client) getPersons(ctx context.Context, method LookupField, ids []string, concurrency int) (map[string]*Person, map[string]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		persons = map[string]*Person{}
+		errs    = map[string]error{}
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		work    = make(chan string)
+	)
+
+	for i := 0; i < concurrency && i < len(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range work {
+				p, err := c.getPerson(ctx, method, id)
+				mu.Lock()
+				if err != nil {
+					errs[id] = err
+				} else {
+					persons[id] = p
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := map[string]bool{}
+feed:
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		select {
+		case work <- id:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	for _, id := range ids {
+		if _, ok := persons[id]; !ok && errs[id] == nil {
+			errs[id] = ctx.Err()
+		}
+	}
+	return persons, errs
+}
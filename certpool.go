@@ -0,0 +1,16 @@
+//go:build !go1.19
+// +build !go1.19
+
+package person_api
+
+import "crypto/x509"
+
+// cloneCertPool returns a pool that can be added to without changing pool.
+// Pools cannot be copied before Go 1.19, so it starts from the system roots
+// instead and the other certificates of pool are not trusted.
+func cloneCertPool(pool *x509.CertPool) *x509.CertPool {
+	if system, err := x509.SystemCertPool(); err == nil {
+		return system
+	}
+	return x509.NewCertPool()
+}
@@ -0,0 +1,12 @@
+//go:build go1.19
+// +build go1.19
+
+package person_api
+
+import "crypto/x509"
+
+// cloneCertPool returns a copy of pool that can be added to without changing
+// pool.
+func cloneCertPool(pool *x509.CertPool) *x509.CertPool {
+	return pool.Clone()
+}
@@ -0,0 +1,122 @@
+package person_api
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit
+// breaker installed by WithCircuitBreaker is open.
+var ErrCircuitOpen = errors.New("Circuit breaker is open, the Person API is failing")
+
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests fast until the cooldown has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe through, whose outcome closes or
+	// reopens the circuit.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker opens after threshold consecutive failed requests, meaning
+// transport errors and 5xx responses.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports ErrCircuitOpen if a request must not be sent now.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+	case CircuitHalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+	return nil
+}
+
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	from := b.state
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.state = CircuitClosed
+	} else {
+		b.failures++
+		if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+			b.state = CircuitOpen
+			b.openedAt = time.Now()
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// abort releases a request that was allowed but ended by its caller, which
+// says nothing about the health of the API.
+func (b *circuitBreaker) abort() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
+
+func (b *circuitBreaker) current() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// CircuitState returns the state of the circuit breaker, CircuitClosed if
+// none is installed.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.current()
+}
@@ -0,0 +1,139 @@
+package person_api_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// usersPage returns a single /v2/users page of n profiles derived from
+// testdata/valid/staff.json, with distinct ids, names and emails. Like the
+// profiles served by CIS, every attribute carries a signature, which is
+// random and so does not compress.
+func usersPage(tb testing.TB, n int) []byte {
+	tb.Helper()
+	staff, err := ioutil.ReadFile("testdata/valid/staff.json")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	random := rand.New(rand.NewSource(1))
+	signature := func() string {
+		// An RS256 JWS: a fixed header, the attribute and 256 bytes of
+		// signature.
+		sig := make([]byte, 256)
+		random.Read(sig)
+		return `"typ": "JWS", "value": "eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9.eyJ2YWx1ZSI6bnVsbH0.` +
+			base64.RawURLEncoding.EncodeToString(sig) + `"`
+	}
+	var page bytes.Buffer
+	page.WriteString(`{"Items": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			page.WriteString(", ")
+		}
+		profile := strings.Replace(string(staff), "jordan", fmt.Sprintf("user%05d", i), -1)
+		profile = strings.Replace(profile, "000000000001", fmt.Sprintf("%012d", i), -1)
+		for strings.Contains(profile, `"typ": "JWS", "value": ""`) {
+			profile = strings.Replace(profile, `"typ": "JWS", "value": ""`, signature(), 1)
+		}
+		page.WriteString(profile)
+	}
+	page.WriteString(`], "nextPage": null}`)
+	return page.Bytes()
+}
+
+func gzipBytes(tb testing.TB, data []byte) []byte {
+	tb.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		tb.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pageHandler serves page, gzipped when the request accepts it and gzipped
+// is set, and counts the bytes written.
+func pageHandler(tb testing.TB, page []byte, gzipped bool, written *int64) http.HandlerFunc {
+	compressed := gzipBytes(tb, page)
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := page
+		if gzipped && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			body = compressed
+		}
+		n, _ := w.Write(body)
+		atomic.AddInt64(written, int64(n))
+	}
+}
+
+func TestGzippedPages(t *testing.T) {
+	const users = 50
+	page := usersPage(t, users)
+	noCompression := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	for _, tt := range []struct {
+		name string
+		opts []person_api.Option
+	}{
+		{"default transport", nil},
+		{"compression disabled", []person_api.Option{person_api.WithHTTPClient(noCompression)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var written int64
+			c, s := newStubClient(t, pageHandler(t, page, true, &written), tt.opts...)
+			defer s.Close()
+
+			persons, err := c.GetAllUsers(context.Background())
+			if err != nil {
+				t.Fatalf("GetAllUsers failed: %v", err)
+			}
+			if len(persons) != users {
+				t.Fatalf("GetAllUsers returned %d users, want %d", len(persons), users)
+			}
+			if got, want := persons[users-1].UserID.Value, fmt.Sprintf("ad|Mozilla-LDAP|user%05d", users-1); got != want {
+				t.Errorf("last user is %s, want %s", got, want)
+			}
+			if written >= int64(len(page)) {
+				t.Errorf("the server sent %d bytes for a page of %d, want it gzipped", written, len(page))
+			}
+		})
+	}
+}
+
+// BenchmarkGetAllUsersCompression fetches a page of 1000 profiles with and
+// without gzip, reporting the bytes sent by the server.
+func BenchmarkGetAllUsersCompression(b *testing.B) {
+	page := usersPage(b, 1000)
+	for _, gzipped := range []bool{false, true} {
+		name := "identity"
+		if gzipped {
+			name = "gzip"
+		}
+		b.Run(name, func(b *testing.B) {
+			var written int64
+			c, s := newStubClient(b, pageHandler(b, page, gzipped, &written))
+			defer s.Close()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.GetAllUsers(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&written))/float64(b.N), "wire-bytes/op")
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package person_api
+
+import (
+	"context"
+	"fmt"
+)
+
+// CredentialsFunc returns the current client id and secret, see
+// WithCredentialsFunc.
+type CredentialsFunc func(ctx context.Context) (id, secret string, err error)
+
+// WithCredentialsFunc makes every token request ask fn for the client
+// credentials instead of using the id and secret given to NewClient, so
+// secrets rotated by a secrets manager are picked up without restarting.
+// fn runs without any lock of the client held but delays the refresh, so it
+// should cache the secret rather than fetch it every time.
+//
+// If fn fails, the refresh fails with its error wrapped, while requests
+// keep using the current token until it actually expires. Cached tokens,
+// see WithTokenCache, stay keyed by the id given to NewClient.
+func WithCredentialsFunc(fn CredentialsFunc) Option {
+	return func(c *Client) error {
+		if fn == nil {
+			return fmt.Errorf("Credentials func must not be nil")
+		}
+		c.credentials = fn
+		return nil
+	}
+}
+
+// clientCredentials returns the credentials of the next token request.
+func (c *Client) clientCredentials(ctx context.Context) (id, secret string, err error) {
+	if c.credentials == nil {
+		return c.clientId, c.clientSecret, nil
+	}
+	id, secret, err = c.credentials(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("Fetching the client credentials failed: %w", err)
+	}
+	if id == "" || secret == "" {
+		return "", "", fmt.Errorf("Credentials func returned an empty client id or secret")
+	}
+	return id, secret, nil
+}
@@ -0,0 +1,107 @@
+package person_api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultDebugBodyLimit is how much of each body WithDebugTransport writes
+// unless WithDebugBodyLimit says otherwise.
+const DefaultDebugBodyLimit = 4096
+
+const redacted = "REDACTED"
+
+var (
+	// secretJSONFields matches the string values of credentials in JSON
+	// bodies: the secret of token requests and the token of their responses.
+	secretJSONFields = regexp.MustCompile(`"(client_secret|access_token|refresh_token|id_token)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// secretFormFields does the same for form encoded bodies.
+	secretFormFields = regexp.MustCompile(`\b(client_secret|access_token|refresh_token)=[^&\s]*`)
+)
+
+// redactSecrets replaces credentials in a request or response body.
+func redactSecrets(body []byte) []byte {
+	body = secretJSONFields.ReplaceAll(body, []byte(`"$1"$2"`+redacted+`"`))
+	return secretFormFields.ReplaceAll(body, []byte(`$1=`+redacted))
+}
+
+// debugTransport writes every request and response to w with credentials
+// redacted by redact and bodies truncated to limit bytes.
+type debugTransport struct {
+	next   http.RoundTripper
+	w      io.Writer
+	limit  int
+	redact func([]byte) []byte
+	mu     sync.Mutex
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = ioutil.ReadAll(body)
+			body.Close()
+		}
+	}
+	dumped := req.Clone(req.Context())
+	if dumped.Header.Get("Authorization") != "" {
+		dumped.Header.Set("Authorization", redacted)
+	}
+	head, _ := httputil.DumpRequestOut(dumped, false)
+
+	resp, err := t.next.RoundTrip(req)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w.Write(t.redact(head))
+	t.writeBody(reqBody)
+	if err != nil {
+		fmt.Fprintf(t.w, "--> transport error: %s\n\n", t.redact([]byte(err.Error())))
+		return nil, err
+	}
+
+	respHead, _ := httputil.DumpResponse(resp, false)
+	t.w.Write(t.redact(respHead))
+	respBody, readErr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	// The caller still gets the full body, whatever was written here.
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	shown := respBody
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if gz, err := gzip.NewReader(bytes.NewReader(respBody)); err == nil {
+			if plain, err := ioutil.ReadAll(gz); err == nil {
+				shown = plain
+			}
+		}
+	}
+	t.writeBody(shown)
+	if readErr != nil {
+		fmt.Fprintf(t.w, "--> reading the body failed: %v\n\n", readErr)
+		return nil, readErr
+	}
+	return resp, nil
+}
+
+func (t *debugTransport) writeBody(body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	body = t.redact(body)
+	truncated := len(body) > t.limit
+	if truncated {
+		body = body[:t.limit]
+	}
+	t.w.Write(body)
+	if truncated {
+		fmt.Fprintf(t.w, "\n[truncated at %d bytes]", t.limit)
+	}
+	io.WriteString(t.w, "\n\n")
+}
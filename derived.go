@@ -0,0 +1,100 @@
+package person_api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithScopes returns a client requesting scopes instead of the scopes of c,
+// for code paths that need more, or fewer, permissions than the rest of a
+// service. The derived client shares the configuration, the HTTP client,
+// the rate limiter and circuit breaker of c, but fetches its token before
+// returning and refreshes it independently, also in the background if c
+// uses WithAutoRefresh. It has its own person cache since profiles depend on
+// the scopes. Closing c stops the derived clients too.
+//
+// Clients without client credentials, as created by NewClientWithToken or
+// NewClientWithTokenSource, cannot request other scopes and fail with
+// ErrNoCredentials.
+func (c *Client) WithScopes(ctx context.Context, scopes ...Scope) (*Client, error) {
+	if !c.hasCredentials() || c.tokenSource != nil {
+		return nil, ErrNoCredentials
+	}
+
+	// The copy shares the configuration of c; only the per-client state is
+	// reset below. The locks keep the token and derived clients of c
+	// consistent while they are copied.
+	c.refreshMu.Lock()
+	c.rwLock.RLock()
+	c.derivedMu.Lock()
+	derived := new(Client)
+	*derived = *c
+	c.derivedMu.Unlock()
+	c.rwLock.RUnlock()
+	c.refreshMu.Unlock()
+
+	derived.accessToken = ""
+	derived.tokenExpiresAt = time.Time{}
+	derived.lastSecret = ""
+	derived.staleToken = ""
+	derived.refreshFailedAt = time.Time{}
+	derived.tokenInfo = TokenInfo{}
+	derived.rwLock = &sync.RWMutex{}
+	derived.refreshMu = &sync.Mutex{}
+	derived.refreshing = nil
+	derived.throttledRetries = new(int64)
+	derived.closeOnce = &sync.Once{}
+	derived.stop, derived.stopped = nil, nil
+	derived.parent = c
+	derived.derivedMu = &sync.Mutex{}
+	derived.derived = nil
+	derived.closed = false
+	if err := WithScopes(NewScopeSet(scopes...))(derived); err != nil {
+		return nil, err
+	}
+	if pc := c.personCache; pc != nil {
+		derived.personCache = newPersonCache(pc.ttl, pc.size)
+		derived.personCache.negativeTTL = pc.negativeTTL
+	}
+	derived.transport = derived.buildTransport()
+
+	if !derived.loadCachedToken() {
+		if err := derived.RefreshAccessToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	c.derivedMu.Lock()
+	defer c.derivedMu.Unlock()
+	if c.closed {
+		return nil, fmt.Errorf("Cannot derive a client from a closed client")
+	}
+	if c.derived == nil {
+		c.derived = map[*Client]bool{}
+	}
+	c.derived[derived] = true
+	derived.startAutoRefresh()
+	return derived, nil
+}
+
+// closeDerived closes the clients derived from c and prevents deriving new
+// ones.
+func (c *Client) closeDerived() {
+	c.derivedMu.Lock()
+	c.closed = true
+	derived := c.derived
+	c.derived = nil
+	c.derivedMu.Unlock()
+	for d := range derived {
+		d.Close()
+	}
+}
+
+// forgetDerived drops d, which was closed, from the clients derived from c.
+func (c *Client) forgetDerived(d *Client) {
+	c.derivedMu.Lock()
+	defer c.derivedMu.Unlock()
+	delete(c.derived, d)
+}
@@ -0,0 +1,194 @@
+package person_api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// scopeServer issues tokens for the requested scopes, living expiresIn
+// seconds, and serves lookups.
+type scopeServer struct {
+	*httptest.Server
+	expiresIn int
+
+	mu      sync.Mutex
+	scopes  []string
+	lookups []string
+}
+
+func newScopeServer(expiresIn int) *scopeServer {
+	s := &scopeServer{expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *scopeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path == "/oauth/token" {
+		var req person_api.AuthReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.scopes = append(s.scopes, req.Scope)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "scope": %q, "expires_in": %d, "token_type": "Bearer"}`,
+			len(s.scopes), req.Scope, s.expiresIn)
+		return
+	}
+	s.lookups = append(s.lookups, r.Header.Get("Authorization"))
+	fmt.Fprint(w, `{"user_id": {"value": "ad|Mozilla-LDAP|user1"}}`)
+}
+
+// Scopes returns the scope of every token request so far.
+func (s *scopeServer) Scopes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.scopes...)
+}
+
+// Lookups returns the Authorization header of every lookup so far.
+func (s *scopeServer) Lookups() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.lookups...)
+}
+
+func (s *scopeServer) newClient(t *testing.T, opts ...person_api.Option) *person_api.Client {
+	t.Helper()
+	opts = append([]person_api.Option{
+		person_api.WithBaseURL(s.URL),
+		person_api.WithAuthURL(s.URL + "/oauth/token"),
+		person_api.WithScopes(person_api.NewScopeSet(person_api.ScopeDisplayPublic)),
+	}, opts...)
+	c, err := person_api.NewClient("id", "secret", opts...)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return c
+}
+
+func TestWithScopesRequestsNewScopes(t *testing.T) {
+	s := newScopeServer(3600)
+	defer s.Close()
+	parent := s.newClient(t)
+	defer parent.Close()
+
+	derived, err := parent.WithScopes(context.Background(), person_api.ScopeDisplayStaff, person_api.ScopeSearchAll)
+	if err != nil {
+		t.Fatalf("WithScopes failed: %v", err)
+	}
+	want := []string{"display:public", "display:staff search:all"}
+	if got := s.Scopes(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("token requests asked for %q, want %q", got, want)
+	}
+	if got := derived.TokenInfo().Scope; got != "display:staff search:all" {
+		t.Errorf("derived token has scope %q", got)
+	}
+
+	if _, err := derived.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user1"); err != nil {
+		t.Fatalf("derived lookup failed: %v", err)
+	}
+	if _, err := parent.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user1"); err != nil {
+		t.Fatalf("parent lookup failed: %v", err)
+	}
+	if got, want := s.Lookups(), []string{"Bearer token-2", "Bearer token-1"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("lookups used %q, want %q", got, want)
+	}
+}
+
+func TestWithScopesLeavesParentUnchanged(t *testing.T) {
+	s := newScopeServer(3600)
+	defer s.Close()
+	parent := s.newClient(t)
+	defer parent.Close()
+	before := parent.TokenInfo()
+
+	derived, err := parent.WithScopes(context.Background(), person_api.ScopeDisplayStaff)
+	if err != nil {
+		t.Fatalf("WithScopes failed: %v", err)
+	}
+	if err := derived.RefreshAccessToken(context.Background()); err != nil {
+		t.Fatalf("refreshing the derived token failed: %v", err)
+	}
+
+	if got := parent.TokenInfo(); got != before {
+		t.Errorf("parent token changed from %+v to %+v", before, got)
+	}
+	if got := parent.GrantedScopes(); fmt.Sprint(got) != "[display:public]" {
+		t.Errorf("parent scopes changed to %q", got)
+	}
+	if got := derived.GrantedScopes(); fmt.Sprint(got) != "[display:staff]" {
+		t.Errorf("derived scopes are %q", got)
+	}
+}
+
+func TestWithScopesHasOwnPersonCache(t *testing.T) {
+	s := newScopeServer(3600)
+	defer s.Close()
+	parent := s.newClient(t, person_api.WithPersonCache(time.Minute, 10))
+	defer parent.Close()
+	derived, err := parent.WithScopes(context.Background(), person_api.ScopeDisplayStaff)
+	if err != nil {
+		t.Fatalf("WithScopes failed: %v", err)
+	}
+
+	for _, c := range []*person_api.Client{parent, parent, derived, derived} {
+		if _, err := c.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user1"); err != nil {
+			t.Fatalf("lookup failed: %v", err)
+		}
+	}
+	// Each client fetches the profile once and then serves it from its own
+	// cache.
+	if got, want := s.Lookups(), []string{"Bearer token-1", "Bearer token-2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("lookups used %q, want %q", got, want)
+	}
+}
+
+func TestCloseStopsDerivedAutoRefresh(t *testing.T) {
+	// Tokens living a second are refreshed by the background refresher
+	// about every second.
+	s := newScopeServer(1)
+	defer s.Close()
+	parent := s.newClient(t, person_api.WithAutoRefresh(time.Hour))
+	derived, err := parent.WithScopes(context.Background(), person_api.ScopeDisplayStaff)
+	if err != nil {
+		t.Fatalf("WithScopes failed: %v", err)
+	}
+
+	derivedRefreshes := func() int {
+		n := 0
+		for _, scope := range s.Scopes() {
+			if scope == "display:staff" {
+				n++
+			}
+		}
+		return n
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for derivedRefreshes() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the derived client never refreshed its token in the background")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	parent.Close()
+	closed := derivedRefreshes()
+	time.Sleep(1500 * time.Millisecond)
+	if got := derivedRefreshes(); got != closed {
+		t.Errorf("the derived client refreshed %d times after its parent was closed", got-closed)
+	}
+	// Closing the derived client again is a no-op.
+	if err := derived.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
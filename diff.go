@@ -0,0 +1,114 @@
+package person_api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffField is an attribute compared by DiffSnapshotsFields, rendered as a
+// string.
+type DiffField struct {
+	Name  string
+	Value func(*Person) string
+}
+
+var (
+	DiffActive = DiffField{Name: "active", Value: func(p *Person) string {
+		return strconv.FormatBool(p.Active.Value)
+	}}
+	// DiffGroups compares the sorted LDAP groups, joined with commas.
+	DiffGroups = DiffField{Name: "ldap_groups", Value: func(p *Person) string {
+		return strings.Join(p.LDAPGroups(), ",")
+	}}
+	DiffPrimaryEmail = DiffField{Name: "primary_email", Value: func(p *Person) string {
+		return p.PrimaryEmail.Value
+	}}
+
+	DefaultDiffFields = []DiffField{DiffActive, DiffGroups, DiffPrimaryEmail}
+)
+
+// ChangeSet is the difference between two snapshots of the directory. All
+// entries are sorted by user_id.
+type ChangeSet struct {
+	Added    []*Person
+	Removed  []*Person
+	Modified []Modification
+}
+
+// Modification is a person present in both snapshots with at least one
+// changed field. Changes are in the order of the compared fields.
+type Modification struct {
+	UserID  string
+	Old     *Person
+	New     *Person
+	Changes []FieldChange
+}
+
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// DiffSnapshots compares two user lists, such as results of GetAllUsers, by
+// user_id using DefaultDiffFields.
+func DiffSnapshots(old, new []*Person) ChangeSet {
+	return DiffSnapshotsFields(old, new, DefaultDiffFields)
+}
+
+// DiffSnapshotsFields is DiffSnapshots comparing the given fields. Persons
+// without a user_id cannot be matched and are ignored.
+func DiffSnapshotsFields(old, new []*Person, fields []DiffField) ChangeSet {
+	oldById := indexByUserId(old)
+	newById := indexByUserId(new)
+
+	var cs ChangeSet
+	for _, id := range sortedKeys(newById) {
+		n := newById[id]
+		o, ok := oldById[id]
+		if !ok {
+			cs.Added = append(cs.Added, n)
+			continue
+		}
+		var changes []FieldChange
+		for _, f := range fields {
+			before, after := f.Value(o), f.Value(n)
+			if before != after {
+				changes = append(changes, FieldChange{Field: f.Name, Before: before, After: after})
+			}
+		}
+		if len(changes) > 0 {
+			cs.Modified = append(cs.Modified, Modification{UserID: id, Old: o, New: n, Changes: changes})
+		}
+	}
+	for _, id := range sortedKeys(oldById) {
+		if _, ok := newById[id]; !ok {
+			cs.Removed = append(cs.Removed, oldById[id])
+		}
+	}
+	return cs
+}
+
+// indexByUserId keeps the first person of every user_id.
+func indexByUserId(persons []*Person) map[string]*Person {
+	index := map[string]*Person{}
+	for _, p := range persons {
+		if p == nil || p.UserID.Value == "" {
+			continue
+		}
+		if _, ok := index[p.UserID.Value]; !ok {
+			index[p.UserID.Value] = p
+		}
+	}
+	return index
+}
+
+func sortedKeys(index map[string]*Person) []string {
+	keys := make([]string, 0, len(index))
+	for k := range index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
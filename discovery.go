@@ -0,0 +1,173 @@
+package person_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultWellKnownURL = "https://auth.mozilla.com/.well-known/mozilla-iam"
+
+	// DefaultDiscoveryTTL is how long a discovery document is cached when
+	// its response carries no Cache-Control max-age.
+	DefaultDiscoveryTTL = time.Hour
+
+	// maxDocumentSize bounds the well-known documents read by this package.
+	maxDocumentSize = 1 << 20
+)
+
+// Discovery is the configuration published in the mozilla-iam well-known
+// document of an IAM deployment.
+type Discovery struct {
+	BaseURL  string
+	AuthURL  string
+	Audience string
+	// PublisherKeys are the keys signing profile attributes, empty if the
+	// document lists none.
+	PublisherKeys PublisherKeys
+	// Expires is when the document should be fetched again.
+	Expires time.Time
+}
+
+type wellKnownDoc struct {
+	OIDCDiscoveryURI string `json:"oidc_discovery_uri"`
+	API              struct {
+		Audience  string            `json:"audience"`
+		Endpoints map[string]string `json:"endpoints"`
+	} `json:"api"`
+	PublisherKeys map[PublisherAuthority]json.RawMessage `json:"publishers_rsa_public_keys"`
+}
+
+var (
+	discoveryHTTPClient = &http.Client{Timeout: DefaultTimeout}
+
+	discoveryMu    sync.Mutex
+	discoveryCache = map[string]*Discovery{}
+)
+
+// DiscoverConfig reads the mozilla-iam well-known document at wellKnownURL,
+// usually DefaultWellKnownURL, and the OpenID configuration it points to for
+// the token endpoint. Documents are cached until they expire; if refreshing
+// an expired one fails the cached copy is returned instead of the error.
+func DiscoverConfig(ctx context.Context, wellKnownURL string) (*Discovery, error) {
+	discoveryMu.Lock()
+	cached := discoveryCache[wellKnownURL]
+	discoveryMu.Unlock()
+	if cached != nil && time.Now().Before(cached.Expires) {
+		copied := *cached
+		return &copied, nil
+	}
+
+	d, err := fetchDiscovery(ctx, wellKnownURL)
+	if err != nil {
+		if cached != nil {
+			copied := *cached
+			return &copied, nil
+		}
+		return nil, err
+	}
+	discoveryMu.Lock()
+	discoveryCache[wellKnownURL] = d
+	discoveryMu.Unlock()
+	copied := *d
+	return &copied, nil
+}
+
+func fetchDiscovery(ctx context.Context, wellKnownURL string) (*Discovery, error) {
+	data, header, err := getDocument(ctx, discoveryHTTPClient, wellKnownURL)
+	if err != nil {
+		return nil, err
+	}
+	var doc wellKnownDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Malformed well-known document: %w", err)
+	}
+	person := doc.API.Endpoints["person"]
+	if person == "" || doc.OIDCDiscoveryURI == "" {
+		return nil, fmt.Errorf("Well-known document at %s lacks the person endpoint or oidc_discovery_uri", wellKnownURL)
+	}
+
+	data, _, err = getDocument(ctx, discoveryHTTPClient, doc.OIDCDiscoveryURI)
+	if err != nil {
+		return nil, err
+	}
+	var oidc struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.Unmarshal(data, &oidc); err != nil {
+		return nil, fmt.Errorf("Malformed OpenID configuration: %w", err)
+	}
+	if oidc.TokenEndpoint == "" {
+		return nil, fmt.Errorf("OpenID configuration at %s lacks a token_endpoint", doc.OIDCDiscoveryURI)
+	}
+
+	keys, err := parsePublisherKeys(doc.PublisherKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &Discovery{
+		// The endpoint is published with its version, which request paths
+		// already carry.
+		BaseURL:       strings.TrimSuffix(strings.TrimSuffix(person, "/"), "/v2"),
+		AuthURL:       oidc.TokenEndpoint,
+		Audience:      doc.API.Audience,
+		PublisherKeys: keys,
+		Expires:       time.Now().Add(cacheTTL(header)),
+	}, nil
+}
+
+// cacheTTL reads max-age from Cache-Control, DefaultDiscoveryTTL otherwise.
+func cacheTTL(header http.Header) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			if secs, err := strconv.Atoi(directive[len("max-age="):]); err == nil && secs > 0 {
+				return time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return DefaultDiscoveryTTL
+}
+
+// getDocument fetches a small unauthenticated document such as a
+// well-known configuration.
+func getDocument(ctx context.Context, httpClient *http.Client, url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, nil, newAPIError(resp)
+	}
+	defer drainAndClose(resp.Body)
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, resp.Header, nil
+}
+
+// NewClientFromDiscovery creates a client for the deployment described by
+// d. opts are applied afterwards and may override the discovered values.
+func NewClientFromDiscovery(d *Discovery, id, secret string, opts ...Option) (*Client, error) {
+	if d == nil {
+		return nil, fmt.Errorf("Discovery must not be nil")
+	}
+	discovered := []Option{WithBaseURL(d.BaseURL), WithAuthURL(d.AuthURL)}
+	if d.Audience != "" {
+		discovered = append(discovered, WithAudience(d.Audience))
+	}
+	return NewClient(id, secret, append(discovered, opts...)...)
+}
@@ -0,0 +1,54 @@
+package person_api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Do sends a request without a body to path, relative to the base URL, and
+// decodes the JSON response into out unless out is nil. It is an escape
+// hatch for endpoints this client does not wrap yet, but goes through the
+// same pipeline as the other methods: the access token, rate limiting,
+// retries of idempotent methods, the circuit breaker, stats and tracing.
+// Responses with a status code of 400 or above fail with an *APIError, or an
+// *UnauthorizedError for a 401.
+//
+//	var resp struct {
+//		Users []string `json:"users"`
+//	}
+//	err := client.Do(ctx, "GET", "/v2/users/id/all", url.Values{"active": {"True"}}, &resp)
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, out interface{}) (err error) {
+	ctx, span := c.startSpan(ctx, "Do")
+	defer func() { span.End(err) }()
+
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("Path must start with a slash, got %q", path)
+	}
+	u, err := url.Parse(joinPath(c.baseUrl, path))
+	if err != nil {
+		return err
+	}
+	if len(query) > 0 {
+		params := u.Query()
+		for key, values := range query {
+			params[key] = values
+		}
+		u.RawQuery = params.Encode()
+	}
+
+	resp, err := c.requestAuthenticated(ctx, method, u.String())
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return c.newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent || method == "HEAD" {
+		drainAndClose(resp.Body)
+		return nil
+	}
+	return decodeAndClose(resp, out)
+}
@@ -0,0 +1,126 @@
+package person_api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+func TestDo(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization is %q", got)
+		}
+		if r.URL.Path != "/v2/users/id/all" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("active") != "True" || q.Get("connectionMethod") != "a b&c" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"users": ["a", "b"], "nextPage": null}`))
+	})
+	defer s.Close()
+
+	var resp struct {
+		Users []string `json:"users"`
+	}
+	query := url.Values{"active": {"True"}, "connectionMethod": {"a b&c"}}
+	if err := c.Do(context.Background(), http.MethodGet, "/v2/users/id/all", query, &resp); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if len(resp.Users) != 2 || resp.Users[0] != "a" || resp.Users[1] != "b" {
+		t.Errorf("Do decoded %v", resp.Users)
+	}
+}
+
+func TestDoStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		status int
+		body   string
+		check  func(error) bool
+	}{
+		{"no content", http.MethodGet, http.StatusNoContent, ``, func(err error) bool { return err == nil }},
+		{"head", http.MethodHead, http.StatusOK, ``, func(err error) bool { return err == nil }},
+		{"malformed JSON", http.MethodGet, http.StatusOK, `{"users": `, func(err error) bool { return err != nil }},
+		{"not found", http.MethodGet, http.StatusNotFound, `{"message": "not found"}`, func(err error) bool {
+			var apiErr *person_api.APIError
+			return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && errors.Is(err, person_api.ErrNotFound)
+		}},
+		{"bad request", http.MethodGet, http.StatusBadRequest, `{"message": "bad"}`, func(err error) bool {
+			var apiErr *person_api.APIError
+			return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.Body == `{"message": "bad"}`
+		}},
+		{"unauthorized", http.MethodGet, http.StatusUnauthorized, ``, func(err error) bool {
+			var unauthorized *person_api.UnauthorizedError
+			return errors.As(err, &unauthorized)
+		}},
+		{"forbidden", http.MethodGet, http.StatusForbidden, ``, func(err error) bool {
+			var forbidden *person_api.ForbiddenError
+			return errors.As(err, &forbidden)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.method {
+					t.Errorf("method is %s, want %s", r.Method, tt.method)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			defer s.Close()
+
+			out := map[string]interface{}{}
+			err := c.Do(context.Background(), tt.method, "/v2/anything", nil, &out)
+			if !tt.check(err) {
+				t.Errorf("Do returned %v", err)
+			}
+		})
+	}
+}
+
+func TestDoRejectsRelativePath(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request for %s", r.URL)
+	})
+	defer s.Close()
+
+	if err := c.Do(context.Background(), http.MethodGet, "v2/users", nil, nil); err == nil {
+		t.Error("Do accepted a path without a leading slash")
+	}
+}
+
+func TestDoRetriesIdempotentMethods(t *testing.T) {
+	for _, tt := range []struct {
+		method string
+		want   int64
+	}{
+		{http.MethodGet, 3},
+		{http.MethodPost, 1},
+	} {
+		t.Run(tt.method, func(t *testing.T) {
+			var requests int64
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt64(&requests, 1)
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			}, person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+			defer s.Close()
+
+			if err := c.Do(context.Background(), tt.method, "/v2/anything", nil, nil); err == nil {
+				t.Error("Do succeeded")
+			}
+			if got := atomic.LoadInt64(&requests); got != tt.want {
+				t.Errorf("%s was sent %d times, want %d", tt.method, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package person_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// String summarizes the identifiers, name, active flag and group count of p
+// as shown at the Public display level, so persons can be logged without
+// leaking restricted attributes.
+func (p *Person) String() string {
+	if p == nil {
+		return "Person<nil>"
+	}
+	s := newPersonSummary(p, Public)
+	var b strings.Builder
+	b.WriteString("Person{")
+	for i, field := range s.fields() {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%s=%s", field.name, field.value)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// Dump writes the summary of p as shown at level to w, one field per line.
+func (p *Person) Dump(w io.Writer, level DisplayLevel) error {
+	if p == nil {
+		_, err := io.WriteString(w, "Person<nil>\n")
+		return err
+	}
+	for _, field := range newPersonSummary(p, level).fields() {
+		if _, err := fmt.Fprintf(w, "%-16s %s\n", field.name+":", field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpVerbose writes every attribute of p visible at level to w as indented
+// JSON, for interactive debugging.
+func (p *Person) DumpVerbose(w io.Writer, level DisplayLevel) error {
+	if p == nil {
+		_, err := io.WriteString(w, "null\n")
+		return err
+	}
+	redacted, err := p.Redact(level)
+	if err != nil {
+		return err
+	}
+	data, err := redacted.Marshal()
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	out.WriteString("\n")
+	_, err = out.WriteTo(w)
+	return err
+}
+
+type summaryField struct {
+	name  string
+	value string
+}
+
+// personSummary shows the attributes of p visible at level. Only the few
+// summarized attributes are checked, so that logging a person does not cost a
+// full Redact.
+type personSummary struct {
+	p     *Person
+	level DisplayLevel
+}
+
+func newPersonSummary(p *Person, level DisplayLevel) personSummary {
+	return personSummary{p: p, level: level}
+}
+
+// visible reports whether attr may be shown at the level of s.
+func (s personSummary) visible(attr interface{}) bool {
+	display, ok := attributeDisplay(reflect.ValueOf(attr))
+	return ok && visibleAt(display, s.level)
+}
+
+func (s personSummary) value(attr StandardAttributeString) string {
+	if !s.visible(attr) {
+		return ""
+	}
+	return attr.Value
+}
+
+// groups counts the groups of the providers visible at the level of s.
+func (s personSummary) groups() int {
+	access := s.p.AccessInformation
+	redactValue(reflect.ValueOf(&access).Elem(), s.level)
+	return len((&Person{AccessInformation: access}).Groups())
+}
+
+func (s personSummary) fields() []summaryField {
+	var fields []summaryField
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, summaryField{name, value})
+		}
+	}
+	add("user_id", s.value(s.p.UserID))
+	add("uuid", s.value(s.p.UUID))
+	add("primary_email", s.value(s.p.PrimaryEmail))
+	add("username", s.value(s.p.PrimaryUsername))
+	if name := strings.TrimSpace(s.value(s.p.FirstName) + " " + s.value(s.p.LastName)); name != "" {
+		add("name", fmt.Sprintf("%q", name))
+	}
+	if _, ok := s.p.ActiveValue(); ok && s.visible(s.p.Active) {
+		add("active", fmt.Sprint(s.p.Active.Value))
+	}
+	add("groups", fmt.Sprint(s.groups()))
+	return fields
+}
@@ -0,0 +1,69 @@
+package person_api_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+var displayLevels = []person_api.DisplayLevel{
+	person_api.Public,
+	person_api.Authenticated,
+	person_api.Vouched,
+	person_api.Ndaed,
+	person_api.Staff,
+	person_api.Private,
+}
+
+// TestDumpMatchesRedact checks that the summary of a person shows what the
+// summary of its fully redacted copy shows.
+func TestDumpMatchesRedact(t *testing.T) {
+	paths, err := filepath.Glob("testdata/valid/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no fixtures: %v", err)
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := decodePerson(t, string(data))
+		for _, level := range displayLevels {
+			redacted, err := p.Redact(level)
+			if err != nil {
+				t.Fatalf("%s: Redact(%s) failed: %v", path, level, err)
+			}
+			var got, want bytes.Buffer
+			if err := p.Dump(&got, level); err != nil {
+				t.Fatalf("%s: Dump(%s) failed: %v", path, level, err)
+			}
+			if err := redacted.Dump(&want, level); err != nil {
+				t.Fatalf("%s: Dump(%s) of the redacted person failed: %v", path, level, err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("%s: Dump(%s) = \n%s\nwant\n%s", path, level, got.String(), want.String())
+			}
+			if level == person_api.Public && p.String() != redacted.String() {
+				t.Errorf("%s: String() = %s, want %s", path, p.String(), redacted.String())
+			}
+		}
+	}
+}
+
+func BenchmarkString(b *testing.B) {
+	data, err := ioutil.ReadFile("testdata/valid/staff.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	p, err := person_api.UnmarshalPerson(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = p.String()
+	}
+}
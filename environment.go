@@ -0,0 +1,98 @@
+package person_api
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment names a CIS deployment for NewClientForEnv.
+type Environment string
+
+const (
+	EnvProduction  Environment = "prod"
+	EnvDevelopment Environment = "dev"
+	EnvTest        Environment = "test"
+)
+
+type environmentURLs struct {
+	baseUrl  string
+	authUrl  string
+	audience string
+}
+
+var environments = map[Environment]environmentURLs{
+	EnvProduction: {
+		baseUrl:  DefaultBaseURL,
+		authUrl:  DefaultAuthURL,
+		audience: DefaultAudience,
+	},
+	EnvDevelopment: {
+		baseUrl:  "https://person.api.dev.sso.allizom.org",
+		authUrl:  "https://auth-dev.mozilla.auth0.com/oauth/token",
+		audience: "api.dev.sso.allizom.org",
+	},
+	EnvTest: {
+		baseUrl:  "https://person.api.test.sso.allizom.org",
+		authUrl:  "https://auth-dev.mozilla.auth0.com/oauth/token",
+		audience: "api.test.sso.allizom.org",
+	},
+}
+
+// NewClientForEnv creates a client for one of the known deployments. opts
+// are applied afterwards, so WithBaseURL and friends still override the
+// preset URLs, for example to point at a local mock.
+func NewClientForEnv(env Environment, id, secret string, opts ...Option) (*Client, error) {
+	urls, ok := environments[env]
+	if !ok {
+		return nil, fmt.Errorf("Unknown environment %q", env)
+	}
+	preset := []Option{
+		WithBaseURL(urls.baseUrl),
+		WithAuthURL(urls.authUrl),
+		WithAudience(urls.audience),
+	}
+	return NewClient(id, secret, append(preset, opts...)...)
+}
+
+// Environment variables read by NewClientFromEnv.
+const (
+	EnvClientID     = "PERSON_API_CLIENT_ID"
+	EnvClientSecret = "PERSON_API_CLIENT_SECRET"
+	EnvBaseURL      = "PERSON_API_BASE_URL"
+	EnvAuthURL      = "PERSON_API_AUTH_URL"
+	EnvScopes       = "PERSON_API_SCOPES"
+	EnvAudience     = "PERSON_API_AUDIENCE"
+)
+
+// NewClientFromEnv creates a client from the PERSON_API_* environment
+// variables. The client id and secret and the base and auth URLs are
+// required, in which case a single error lists every missing variable. The
+// space or comma separated PERSON_API_SCOPES and PERSON_API_AUDIENCE are
+// optional. opts are applied afterwards and override the environment.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	var missing []string
+	require := func(name string) string {
+		value := os.Getenv(name)
+		if value == "" {
+			missing = append(missing, name)
+		}
+		return value
+	}
+	id := require(EnvClientID)
+	secret := require(EnvClientSecret)
+	baseUrl := require(EnvBaseURL)
+	authUrl := require(EnvAuthURL)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("Missing environment variables: %s", strings.Join(missing, ", "))
+	}
+
+	fromEnv := []Option{WithBaseURL(baseUrl), WithAuthURL(authUrl)}
+	if scopes := ParseScopes(os.Getenv(EnvScopes)); len(scopes) > 0 {
+		fromEnv = append(fromEnv, WithScopes(scopes))
+	}
+	if audience := os.Getenv(EnvAudience); audience != "" {
+		fromEnv = append(fromEnv, WithAudience(audience))
+	}
+	return NewClient(id, secret, append(fromEnv, opts...)...)
+}
@@ -0,0 +1,266 @@
+package person_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrNotFound is reported, possibly wrapped, when the requested person does
+// not exist.
+var ErrNotFound = errors.New("Person not found")
+
+// ErrNoCredentials is returned when a client created with
+// NewClientWithToken is asked to fetch a token.
+var ErrNoCredentials = errors.New("Client has no credentials to request an access token")
+
+// ErrLimitExceeded is reported, as a *LimitError, when an enumeration
+// exceeds the limits set with WithMaxPages or WithMaxUsers.
+var ErrLimitExceeded = errors.New("Enumeration limit exceeded")
+
+// ErrRepeatedCursor is reported, wrapped with the number of pages fetched,
+// when the API hands out the cursor of a page the enumeration already
+// fetched, which would otherwise loop forever.
+var ErrRepeatedCursor = errors.New("Enumeration returned the cursor of an earlier page")
+
+// LimitError names the enumeration limit that was exceeded.
+type LimitError struct {
+	// Limit is "pages" or "users".
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("Enumeration exceeded the limit of %d %s", e.Max, e.Limit)
+}
+
+// Is makes errors.Is(err, ErrLimitExceeded) hold.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// UnknownLookupFieldError is returned for a LookupField that does not name a
+// field the API can look persons up by.
+type UnknownLookupFieldError struct {
+	Field string
+}
+
+func (e *UnknownLookupFieldError) Error() string {
+	return fmt.Sprintf("Unknown lookup field %q", e.Field)
+}
+
+// StrictDecodingError is returned by UnmarshalPersonStrict, and by clients
+// created with WithStrictDecoding, for a profile the client cannot decode
+// without losing fields. Err names the offending field.
+type StrictDecodingError struct {
+	// UserID is the user id of the profile, if it could be read.
+	UserID string
+	Err    error
+}
+
+func (e *StrictDecodingError) Error() string {
+	if e.UserID == "" {
+		return fmt.Sprintf("Strict decoding of profile failed: %v", e.Err)
+	}
+	return fmt.Sprintf("Strict decoding of profile %s failed: %v", e.UserID, e.Err)
+}
+
+func (e *StrictDecodingError) Unwrap() error {
+	return e.Err
+}
+
+func newStrictDecodingError(data []byte, err error) *StrictDecodingError {
+	var id struct {
+		UserID struct {
+			Value string `json:"value"`
+		} `json:"user_id"`
+	}
+	// Best effort, the profile may be malformed beyond the unknown field.
+	json.Unmarshal(data, &id)
+	return &StrictDecodingError{UserID: id.UserID.Value, Err: err}
+}
+
+// AmbiguousMatchError is returned by lookups without a unique key, such as
+// GetPersonByGithubUsername, when several persons match.
+type AmbiguousMatchError struct {
+	Attribute string
+	Value     string
+	// UserIDs are the sorted user ids of the matching persons.
+	UserIDs []string
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	return fmt.Sprintf("%d persons match %s %q: %s", len(e.UserIDs), e.Attribute, e.Value, strings.Join(e.UserIDs, ", "))
+}
+
+// maxErrorBodySize bounds how much of an error response is kept on an
+// APIError. The body is redacted before it is truncated, reading at most
+// maxErrorReadSize of it, so that a secret cut at the limit is still
+// recognized.
+const (
+	maxErrorBodySize = 4096
+	maxErrorReadSize = 1 << 20
+)
+
+// APIError describes a response with a status code of 400 or above from the
+// Person API or the auth endpoint. Use errors.As to inspect it.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	// Body holds the start of the response body, truncated to a few KiB.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("Persons API responded with status code %d to %s %s", e.StatusCode, e.Method, e.URL)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrNotFound) hold for 404 responses.
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// newAPIError consumes and closes the body of resp. Credentials in the body
+// and URL are redacted with DefaultRedactor.
+func newAPIError(resp *http.Response) *APIError {
+	return newRedactedAPIError(resp, redactSecrets)
+}
+
+// newRedactedAPIError is newAPIError with the body and URL redacted by
+// redact.
+func newRedactedAPIError(resp *http.Response, redact Redactor) *APIError {
+	defer drainAndClose(resp.Body)
+	var body []byte
+	if decoded, err := decodedBody(resp); err == nil {
+		body, _ = ioutil.ReadAll(io.LimitReader(decoded, maxErrorReadSize))
+	}
+	body = redact(body)
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = string(redact([]byte(resp.Request.URL.String())))
+	}
+	return e
+}
+
+// UnauthorizedError is returned when the Person API still answers 401 after
+// the access token has been refreshed. Unlike a plain expired token this
+// points at the client credentials or their grants. Clients created with
+// NewClientWithToken report it for the first 401.
+type UnauthorizedError struct {
+	*APIError
+}
+
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("Persons API rejected the access token for %s %s", e.Method, e.URL)
+}
+
+func (e *UnauthorizedError) Unwrap() error {
+	return e.APIError
+}
+
+// ForbiddenError is returned when the Person API answers 403, typically
+// because the client was not granted the scopes a request needs.
+type ForbiddenError struct {
+	*APIError
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("Persons API denied access to %s %s", e.Method, e.URL)
+}
+
+func (e *ForbiddenError) Unwrap() error {
+	return e.APIError
+}
+
+// AuthError is returned when the auth endpoint rejects the token request
+// with an OAuth error body, e.g. Code "access_denied" and Description
+// "Unauthorized" for a wrong client secret. Responses without such a body
+// are reported as a plain *APIError.
+type AuthError struct {
+	Code        string
+	Description string
+	*APIError
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("Auth server rejected the token request with status code %d: %s", e.StatusCode, e.Code)
+	if e.Description != "" {
+		msg += " (" + e.Description + ")"
+	}
+	return msg
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.APIError
+}
+
+// newAuthError consumes and closes the body of resp.
+func (c *Client) newAuthError(resp *http.Response) error {
+	apiErr := c.newAPIError(resp)
+	var body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal([]byte(apiErr.Body), &body); err != nil || body.Error == "" {
+		return apiErr
+	}
+	return &AuthError{
+		Code:        body.Error,
+		Description: body.ErrorDescription,
+		APIError:    apiErr,
+	}
+}
+
+// SyncError reports an incremental sync, such as
+// GetPersonsModifiedSinceWithOptions, that was interrupted. Passing Cursor as
+// ModifiedSinceOptions.Cursor resumes the run at the page that failed.
+type SyncError struct {
+	// Cursor is the cursor of the failed page, empty for the first page.
+	Cursor string
+	Err    error
+}
+
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("Incremental sync was interrupted: %v", e.Err)
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// PageError reports an enumeration that failed part way through. Passing
+// Cursor back, for example to GetUsersPage, resumes the enumeration at the
+// page that could not be fetched.
+type PageError struct {
+	cursor string
+	Err    error
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("Fetching a page of users failed: %v", e.Err)
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// Cursor is the cursor of the failed page, empty for the first page.
+func (e *PageError) Cursor() string {
+	return e.cursor
+}
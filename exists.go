@@ -0,0 +1,68 @@
+package person_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// personIdentifiers is the part of a profile exists decodes, enough to tell
+// a profile from the hollow answer to an unknown identifier.
+type personIdentifiers struct {
+	UserID       StandardAttributeString `json:"user_id"`
+	UUID         StandardAttributeString `json:"uuid"`
+	PrimaryEmail StandardAttributeString `json:"primary_email"`
+}
+
+// ExistsByEmail reports whether a person has primaryEmail as primary email.
+func (c *Client) ExistsByEmail(ctx context.Context, primaryEmail string) (bool, error) {
+	return c.exists(ctx, PRIMARY_EMAIL, primaryEmail)
+}
+
+// ExistsByUserID reports whether a person has the user id userid.
+func (c *Client) ExistsByUserID(ctx context.Context, userid string) (bool, error) {
+	return c.exists(ctx, USERID, userid)
+}
+
+// exists is a lookup like getPerson that only decodes the identifiers of
+// the profile, and neither decodes nor verifies the rest. It answers from
+// the person cache and revalidates the response cache, but only caches
+// unknown identifiers itself: profiles it has not fully decoded and
+// verified must not be served by getPerson. A person that is not found is
+// reported as false without an error.
+func (c *Client) exists(ctx context.Context, method LookupField, id string) (found bool, err error) {
+	ctx, span := c.startSpan(ctx, "Exists")
+	defer func() { span.End(err) }()
+
+	personUrl, err := c.personURL(method, id)
+	if err != nil {
+		return false, err
+	}
+
+	personKey := personCacheKey(method, id)
+	if _, ok, err := c.cachedPerson(ctx, personKey); ok {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	body, err := c.fetchPerson(ctx, personUrl)
+	if errors.Is(err, ErrNotFound) {
+		c.storePerson(personKey, nil, ErrNotFound)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var ids personIdentifiers
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return false, err
+	}
+	if ids.UserID.Value == "" && ids.UUID.Value == "" && ids.PrimaryEmail.Value == "" {
+		c.storePerson(personKey, nil, ErrNotFound)
+		return false, nil
+	}
+	return true, nil
+}
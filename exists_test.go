@@ -0,0 +1,133 @@
+package person_api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+func TestExists(t *testing.T) {
+	profile, err := person_api.MarshalPerson(*newTestPerson(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantFound bool
+		wantErr   bool
+	}{
+		{"found", http.StatusOK, string(profile), true, false},
+		{"404", http.StatusNotFound, `{"message": "Not found"}`, false, false},
+		{"empty object", http.StatusOK, `{}`, false, false},
+		{"null attributes", http.StatusOK, `{"user_id": {"value": null}, "uuid": {"value": null}, "primary_email": {"value": null}}`, false, false},
+		{"server error", http.StatusInternalServerError, ``, false, true},
+		{"malformed", http.StatusOK, `{"user_id": [`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}, person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1}))
+			defer s.Close()
+
+			found, err := c.ExistsByEmail(context.Background(), "user1@mozilla.com")
+			if found != tt.wantFound || (err != nil) != tt.wantErr {
+				t.Errorf("ExistsByEmail = %v, %v, want %v and an error: %v", found, err, tt.wantFound, tt.wantErr)
+			}
+			if errors.Is(err, person_api.ErrNotFound) {
+				t.Errorf("ExistsByEmail returned ErrNotFound")
+			}
+		})
+	}
+}
+
+func TestExistsCaches(t *testing.T) {
+	profile, err := person_api.MarshalPerson(*newTestPerson(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests, notModified int64
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if r.URL.Path != "/v2/user/user_id/ad|Mozilla-LDAP|user1" {
+			http.Error(w, `{"message": "Not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt64(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write(profile)
+	}, person_api.WithPersonCache(time.Minute, 10), person_api.WithNegativePersonCache(time.Minute),
+		person_api.WithResponseCache(person_api.NewMemoryResponseCache(10)))
+	defer s.Close()
+	ctx := context.Background()
+
+	// A profile found by exists is only kept in the response cache, so
+	// the lookup after it revalidates instead of downloading it again.
+	if found, err := c.ExistsByUserID(ctx, "ad|Mozilla-LDAP|user1"); !found || err != nil {
+		t.Fatalf("ExistsByUserID = %v, %v, want true", found, err)
+	}
+	p, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|user1")
+	if err != nil || p.PrimaryEmail.Value != "user1@mozilla.com" {
+		t.Fatalf("GetPersonByUserId = %v, %v", p, err)
+	}
+	if got := atomic.LoadInt64(&notModified); got != 1 {
+		t.Errorf("GetPersonByUserId got %d 304 answers, want 1", got)
+	}
+	// The profile is now in the person cache.
+	if found, err := c.ExistsByUserID(ctx, "ad|Mozilla-LDAP|user1"); !found || err != nil {
+		t.Fatalf("ExistsByUserID = %v, %v, want true", found, err)
+	}
+
+	// Unknown identifiers are cached negatively by exists.
+	if found, err := c.ExistsByUserID(ctx, "ad|Mozilla-LDAP|user2"); found || err != nil {
+		t.Fatalf("ExistsByUserID = %v, %v, want false", found, err)
+	}
+	if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|user2"); !errors.Is(err, person_api.ErrNotFound) {
+		t.Fatalf("GetPersonByUserId = %v, want ErrNotFound", err)
+	}
+	if found, err := c.ExistsByUserID(ctx, "ad|Mozilla-LDAP|user2"); found || err != nil {
+		t.Fatalf("ExistsByUserID = %v, %v, want false", found, err)
+	}
+
+	if got := atomic.LoadInt64(&requests); got != 3 {
+		t.Errorf("the lookups made %d requests, want 3", got)
+	}
+}
+
+func TestExistsDecodesOnlyIdentifiers(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"user_id": {"value": "ad|Mozilla-LDAP|user1"}, "active": {"value": "yes"}}`)
+	})
+	defer s.Close()
+
+	if _, err := c.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user1"); err == nil {
+		t.Fatal("GetPersonByUserId decoded a malformed active attribute")
+	}
+	if found, err := c.ExistsByUserID(context.Background(), "ad|Mozilla-LDAP|user1"); !found || err != nil {
+		t.Errorf("ExistsByUserID = %v, %v, want true", found, err)
+	}
+}
+
+func TestExistsEmptyIdentifier(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent for an empty identifier")
+	})
+	defer s.Close()
+
+	if found, err := c.ExistsByEmail(context.Background(), ""); found || err == nil {
+		t.Errorf("ExistsByEmail(\"\") = %v, %v, want an error", found, err)
+	}
+}
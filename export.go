@@ -0,0 +1,181 @@
+package person_api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Column is a column of a CSV export, see WriteCSV.
+type Column struct {
+	Header string
+	// Value extracts the cell of a person. It is never called with nil.
+	Value func(p *Person) string
+}
+
+// Built-in columns for CSV exports. Absent attributes become empty cells.
+var (
+	ColumnUserID = Column{Header: "user_id", Value: func(p *Person) string {
+		return p.UserID.Value
+	}}
+	ColumnPrimaryEmail = Column{Header: "primary_email", Value: func(p *Person) string {
+		return p.PrimaryEmail.Value
+	}}
+	// ColumnName joins the first and last name.
+	ColumnName = Column{Header: "name", Value: func(p *Person) string {
+		return strings.TrimSpace(p.FirstName.Value + " " + p.LastName.Value)
+	}}
+	ColumnActive = Column{Header: "active", Value: func(p *Person) string {
+		return strconv.FormatBool(p.Active.Value)
+	}}
+	ColumnCostCenter = Column{Header: "cost_center", Value: func(p *Person) string {
+		costCenter, _ := p.CostCenter()
+		return costCenter
+	}}
+	// ColumnLDAPGroups joins the sorted LDAP groups with ";".
+	ColumnLDAPGroups = Column{Header: "ldap_groups", Value: func(p *Person) string {
+		return strings.Join(p.LDAPGroups(), ";")
+	}}
+)
+
+// DefaultColumns are exported when WriteCSV is given no columns.
+var DefaultColumns = []Column{ColumnUserID, ColumnPrimaryEmail, ColumnName, ColumnActive, ColumnCostCenter, ColumnLDAPGroups}
+
+// WriteCSV writes a header row and one row per person to w, skipping nil
+// persons. Cells are quoted as needed by encoding/csv.
+func WriteCSV(w io.Writer, persons []*Person, columns []Column) error {
+	cw := NewCSVWriter(w, columns)
+	for _, p := range persons {
+		if err := cw.Write(p); err != nil {
+			return err
+		}
+	}
+	return cw.Flush()
+}
+
+// CSVWriter writes persons as CSV one row at a time, so exports fed by
+// ForEachUser or StreamAllUsers never hold the whole directory.
+//
+//	cw := person_api.NewCSVWriter(w, person_api.DefaultColumns)
+//	err := client.ForEachUser(ctx, cw.Write)
+//	if err == nil {
+//		err = cw.Flush()
+//	}
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []Column
+	header  bool
+	row     []string
+}
+
+// NewCSVWriter returns a writer of the columns, or DefaultColumns if there
+// are none.
+func NewCSVWriter(w io.Writer, columns []Column) *CSVWriter {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	return &CSVWriter{w: csv.NewWriter(w), columns: columns, row: make([]string, len(columns))}
+}
+
+// Write writes the row of p, preceded by the header row on the first call.
+// Nil persons are skipped.
+func (c *CSVWriter) Write(p *Person) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	for i, column := range c.columns {
+		c.row[i] = ""
+		if column.Value != nil {
+			c.row[i] = column.Value(p)
+		}
+	}
+	return c.w.Write(c.row)
+}
+
+// Flush writes any buffered rows, and the header row if no person was
+// written, to the underlying writer.
+func (c *CSVWriter) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSVWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	for i, column := range c.columns {
+		c.row[i] = column.Header
+	}
+	return c.w.Write(c.row)
+}
+
+// DefaultJSONLinesFlushEvery is the number of lines WriteJSONLines buffers
+// before writing them out.
+const DefaultJSONLinesFlushEvery = 100
+
+// JSONLinesOptions tune WriteJSONLinesWithOptions.
+type JSONLinesOptions struct {
+	// Simplified writes the flat SimplePerson form of each person instead
+	// of the full CIS profile.
+	Simplified bool
+	// FlushEvery is the number of lines buffered between writes to w,
+	// DefaultJSONLinesFlushEvery if zero.
+	FlushEvery int
+}
+
+// WriteJSONLines writes the persons received from persons to w as CIS
+// profiles, one JSON object per line, until the channel is closed. It is
+// meant to consume StreamAllUsers directly. Nil persons are skipped.
+//
+// On a write error WriteJSONLines stops reading and returns the error; the
+// producer should then be stopped by cancelling its context.
+func WriteJSONLines(w io.Writer, persons <-chan *Person) error {
+	return WriteJSONLinesWithOptions(w, persons, JSONLinesOptions{})
+}
+
+// WriteJSONLinesWithOptions is WriteJSONLines with the output form and
+// buffering set by opts. Lines are written to w in batches of
+// opts.FlushEvery, and whatever is still buffered is flushed once persons is
+// closed. On a write or encoding error it stops reading and returns the
+// error, and lines buffered since the last flush are not written.
+func WriteJSONLinesWithOptions(w io.Writer, persons <-chan *Person, opts JSONLinesOptions) error {
+	flushEvery := opts.FlushEvery
+	if flushEvery <= 0 {
+		flushEvery = DefaultJSONLinesFlushEvery
+	}
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	lines := 0
+	for p := range persons {
+		if p == nil {
+			continue
+		}
+		var err error
+		if opts.Simplified {
+			err = enc.Encode(p.Simplify())
+		} else {
+			err = enc.Encode(p)
+		}
+		if err != nil {
+			return err
+		}
+		if lines++; lines%flushEvery == 0 {
+			if err := buf.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return buf.Flush()
+}
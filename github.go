@@ -0,0 +1,60 @@
+package person_api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetPersonByGithubUsername looks up the active person whose usernames list
+// the GitHub login, compared case-insensitively. The API has no route for
+// this, so the profiles are found with the attribute filter, or by listing
+// all active users if the filter is rejected. No match is reported as
+// ErrNotFound and several as an *AmbiguousMatchError.
+func (c *Client) GetPersonByGithubUsername(ctx context.Context, login string) (*Person, error) {
+	if login == "" {
+		return nil, fmt.Errorf("Cannot look up a person by an empty GitHub username")
+	}
+	return c.findUnique(ctx, "usernames", login, func(p *Person) bool {
+		return hasGithubUsername(p, login)
+	})
+}
+
+// GetPersonByGithubID looks up the active person with the GitHub user id,
+// either the numeric REST id (identities.github_id_v3) or the GraphQL node
+// id (identities.github_id_v4). It reports no or several matches like
+// GetPersonByGithubUsername.
+func (c *Client) GetPersonByGithubID(ctx context.Context, id string) (*Person, error) {
+	if id == "" {
+		return nil, fmt.Errorf("Cannot look up a person by an empty GitHub id")
+	}
+	attr, value := "identities.github_id_v4", (*Person).GithubIDV4
+	if isNumeric(id) {
+		attr, value = "identities.github_id_v3", (*Person).GithubIDV3
+	}
+	return c.findUnique(ctx, attr, id, func(p *Person) bool {
+		v, ok := value(p)
+		return ok && v == id
+	})
+}
+
+// hasGithubUsername reports whether one of the GitHub entries of the
+// usernames of p, such as "HACK#GITHUB", is login.
+func hasGithubUsername(p *Person, login string) bool {
+	usernames, _ := p.UsernamesValues()
+	for key, username := range usernames {
+		if strings.Contains(strings.ToLower(key), "github") && strings.EqualFold(username, login) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -1,3 +1,8 @@
 module go.mozilla.org/person-api
 
 go 1.13
+
+require (
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+)
@@ -0,0 +1,359 @@
+package person_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// GroupRef names a group of a given provider. An empty Provider refers to
+// a group of that name from any provider.
+type GroupRef struct {
+	Provider PublisherAuthority
+	Name     string
+}
+
+// GroupProviders are the providers of the access_information blocks.
+var GroupProviders = []PublisherAuthority{LDAP, Hris, Mozilliansorg, AccessProvider}
+
+func (r GroupRef) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("Group name must not be empty")
+	}
+	if r.Provider != "" && !isGroupProvider(r.Provider) {
+		return fmt.Errorf("Unknown group provider %q", r.Provider)
+	}
+	return nil
+}
+
+func isGroupProvider(provider PublisherAuthority) bool {
+	for _, p := range GroupProviders {
+		if provider == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (r GroupRef) providers() []PublisherAuthority {
+	if r.Provider == "" {
+		return GroupProviders
+	}
+	return []PublisherAuthority{r.Provider}
+}
+
+// matches is safe for nil persons and missing access_information blocks.
+func (r GroupRef) matches(person *Person) bool {
+	for _, provider := range r.providers() {
+		if _, ok := groupValues(person, provider)[r.Name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func ldapRefs(groups []string) []GroupRef {
+	refs := make([]GroupRef, len(groups))
+	for i, g := range groups {
+		refs[i] = GroupRef{Provider: LDAP, Name: g}
+	}
+	return refs
+}
+
+// GetPersonsInGroups returns the active staff members that are in any of the
+// given LDAP groups, without duplicates and sorted by user_id. Each group is
+// queried with the server-side attribute filter; if the server rejects the
+// filter, all active staff are fetched and matched locally instead.
+func (c *Client) GetPersonsInGroups(ctx context.Context, groups []string) ([]*Person, error) {
+	return c.GetPersonsInGroupRefs(ctx, ldapRefs(groups))
+}
+
+// GetPersonsInAllGroups is like GetPersonsInGroups but only returns the
+// persons that are in every one of the given groups.
+func (c *Client) GetPersonsInAllGroups(ctx context.Context, groups []string) ([]*Person, error) {
+	return c.GetPersonsInAllGroupRefs(ctx, ldapRefs(groups))
+}
+
+// GetPersonsInGroupRefs is GetPersonsInGroups for groups of any provider.
+func (c *Client) GetPersonsInGroupRefs(ctx context.Context, refs []GroupRef) ([]*Person, error) {
+	for _, r := range refs {
+		if err := r.validate(); err != nil {
+			return []*Person{}, err
+		}
+	}
+	return c.collectGroupMembers(ctx, refs, func(p *Person) bool {
+		for _, r := range refs {
+			if r.matches(p) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// GetPersonsInAllGroupRefs is GetPersonsInAllGroups for groups of any
+// provider.
+func (c *Client) GetPersonsInAllGroupRefs(ctx context.Context, refs []GroupRef) ([]*Person, error) {
+	if len(refs) == 0 {
+		return []*Person{}, nil
+	}
+	for _, r := range refs {
+		if err := r.validate(); err != nil {
+			return []*Person{}, err
+		}
+	}
+	// Members of all groups are members of the first one, so that is the
+	// only one worth listing.
+	return c.collectGroupMembers(ctx, refs[:1], func(p *Person) bool {
+		for _, r := range refs {
+			if !r.matches(p) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// collectGroupMembers lists the members of the queried groups and keeps the
+// active staff for which match holds.
+func (c *Client) collectGroupMembers(ctx context.Context, queried []GroupRef, match func(*Person) bool) ([]*Person, error) {
+	var candidates []*Person
+	for _, ref := range queried {
+		for _, provider := range ref.providers() {
+			attrs := url.Values{}
+			attrs.Set("access_information."+string(provider), ref.Name)
+			persons, err := c.getByAttribute(ctx, attrs)
+			if isRejectedFilter(err) {
+				return c.scanGroupMembers(ctx, match)
+			}
+			if err != nil {
+				return []*Person{}, err
+			}
+			candidates = append(candidates, persons...)
+		}
+	}
+
+	// The filter matches on containment, so the exact groups and the staff
+	// restriction are checked again by filterGroupMembers.
+	return filterGroupMembers(candidates, match), nil
+}
+
+// scanGroupMembers matches all active staff locally.
+func (c *Client) scanGroupMembers(ctx context.Context, match func(*Person) bool) ([]*Person, error) {
+	persons, err := c.GetAllActiveStaff(ctx)
+	if err != nil {
+		return []*Person{}, err
+	}
+	return filterGroupMembers(persons, match), nil
+}
+
+func filterGroupMembers(candidates []*Person, match func(*Person) bool) []*Person {
+	collectedPersons := []*Person{}
+	for _, person := range Dedupe(candidates) {
+		if match(person) && person.IsStaff() {
+			collectedPersons = append(collectedPersons, person)
+		}
+	}
+	sort.SliceStable(collectedPersons, func(i, j int) bool {
+		return collectedPersons[i].UserID.Value < collectedPersons[j].UserID.Value
+	})
+	return collectedPersons
+}
+
+// GroupMatchOptions relaxes how group names are compared with the patterns
+// given to GetPersonsInGroupsMatching and GetPersonsInAllGroupsMatching.
+type GroupMatchOptions struct {
+	CaseInsensitive bool
+	// Glob treats patterns as path.Match patterns, e.g. "mobile_*".
+	Glob bool
+}
+
+type groupMatcher struct {
+	patterns []string
+	opts     GroupMatchOptions
+}
+
+func newGroupMatcher(patterns []string, opts GroupMatchOptions) (*groupMatcher, error) {
+	m := &groupMatcher{opts: opts}
+	for _, p := range patterns {
+		if opts.CaseInsensitive {
+			p = strings.ToLower(p)
+		}
+		if opts.Glob {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("Invalid group pattern %q: %w", p, err)
+			}
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+func (m *groupMatcher) matches(pattern, group string) bool {
+	if m.opts.CaseInsensitive {
+		group = strings.ToLower(group)
+	}
+	if m.opts.Glob {
+		ok, _ := path.Match(pattern, group)
+		return ok
+	}
+	return pattern == group
+}
+
+// matchesPattern reports whether any LDAP group of person matches pattern.
+func (m *groupMatcher) matchesPattern(person *Person, pattern string) bool {
+	for group := range groupValues(person, LDAP) {
+		if m.matches(pattern, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *groupMatcher) matchesAny(person *Person) bool {
+	for _, p := range m.patterns {
+		if m.matchesPattern(person, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *groupMatcher) matchesAll(person *Person) bool {
+	for _, p := range m.patterns {
+		if !m.matchesPattern(person, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetPersonsInGroupsMatching is GetPersonsInGroups with patterns compared
+// according to opts. Invalid glob patterns are reported before any request
+// is made. Unless opts are the zero value, all active staff are fetched and
+// matched locally.
+func (c *Client) GetPersonsInGroupsMatching(ctx context.Context, patterns []string, opts GroupMatchOptions) ([]*Person, error) {
+	m, err := newGroupMatcher(patterns, opts)
+	if err != nil {
+		return []*Person{}, err
+	}
+	if opts == (GroupMatchOptions{}) {
+		return c.GetPersonsInGroups(ctx, patterns)
+	}
+	return c.scanGroupMembers(ctx, m.matchesAny)
+}
+
+// GetPersonsInAllGroupsMatching is GetPersonsInAllGroups with patterns
+// compared according to opts: every pattern has to match at least one group.
+func (c *Client) GetPersonsInAllGroupsMatching(ctx context.Context, patterns []string, opts GroupMatchOptions) ([]*Person, error) {
+	m, err := newGroupMatcher(patterns, opts)
+	if err != nil {
+		return []*Person{}, err
+	}
+	if opts == (GroupMatchOptions{}) {
+		return c.GetPersonsInAllGroups(ctx, patterns)
+	}
+	return c.scanGroupMembers(ctx, m.matchesAll)
+}
+
+// isRejectedFilter reports whether err is the API refusing a query
+// parameter, as opposed to failing altogether.
+func isRejectedFilter(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
+
+// groupValues returns the access_information block of person for provider,
+// which maps group names to their metadata. It is nil when person is nil or
+// the block is absent: a missing access_information or provider block decodes
+// to its zero value, whose Values are nil, and ranging over nil is a no-op.
+// All group scans go through here so they never dereference a missing level.
+func groupValues(person *Person, provider PublisherAuthority) map[string]interface{} {
+	if person == nil {
+		return nil
+	}
+	switch provider {
+	case LDAP:
+		return person.AccessInformation.LDAP.Values
+	case Hris:
+		return person.AccessInformation.Hris.Values
+	case Mozilliansorg:
+		return person.AccessInformation.Mozilliansorg.Values
+	case AccessProvider:
+		return person.AccessInformation.AccessProvider.Values
+	}
+	return nil
+}
+
+// ListGroups counts the members of every group found in the directory,
+// walking all users once without holding them in memory. Only LDAP groups are
+// considered unless other providers, such as Hris or Mozilliansorg, are
+// given. Groups of the same name from different providers are counted
+// together, each person at most once.
+func (c *Client) ListGroups(ctx context.Context, providers ...PublisherAuthority) (map[string]int, error) {
+	if len(providers) == 0 {
+		providers = []PublisherAuthority{LDAP}
+	}
+	for _, provider := range providers {
+		if !isGroupProvider(provider) {
+			return nil, fmt.Errorf("Unknown group provider %q", provider)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	counts := map[string]int{}
+	persons, errs := c.StreamAllUsers(ctx)
+	for person := range persons {
+		seen := map[string]bool{}
+		for _, provider := range providers {
+			for group := range groupValues(person, provider) {
+				if !seen[group] {
+					seen[group] = true
+					counts[group]++
+				}
+			}
+		}
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// GroupMembers returns the sorted, distinct primary emails of the members of
+// an LDAP group, as determined by GetPersonsInGroups. Members without a
+// primary email are skipped.
+func (c *Client) GroupMembers(ctx context.Context, group string) ([]string, error) {
+	return c.groupMemberValues(ctx, group, func(p *Person) string { return p.PrimaryEmail.Value })
+}
+
+// GroupMemberUserIds is GroupMembers returning user_ids.
+func (c *Client) GroupMemberUserIds(ctx context.Context, group string) ([]string, error) {
+	return c.groupMemberValues(ctx, group, func(p *Person) string { return p.UserID.Value })
+}
+
+func (c *Client) groupMemberValues(ctx context.Context, group string, value func(*Person) string) ([]string, error) {
+	persons, err := c.GetPersonsInGroups(ctx, []string{group})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	values := []string{}
+	for _, p := range persons {
+		v := value(p)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values, nil
+}
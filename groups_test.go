@@ -0,0 +1,121 @@
+package person_api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// groupFixtures are staff profiles missing each level of the structure
+// leading to the LDAP groups, besides one member of team_a. The members of
+// access_information are spliced into the profile as is.
+var groupFixtures = []struct {
+	userID string
+	access string
+}{
+	{"member", `"access_information": {"ldap": {"values": {"team_a": null}}}`},
+	{"no access_information", ``},
+	{"null access_information", `"access_information": null`},
+	{"no ldap", `"access_information": {"hris": {"values": {"team_a": null}}}`},
+	{"null ldap", `"access_information": {"ldap": null}`},
+	{"no values", `"access_information": {"ldap": {"metadata": {"display": "staff"}}}`},
+	{"null values", `"access_information": {"ldap": {"values": null}}`},
+	{"empty values", `"access_information": {"ldap": {"values": {}}}`},
+}
+
+// groupFixtureProfile returns the JSON profile of the i-th groupFixture.
+func groupFixtureProfile(i int) string {
+	f := groupFixtures[i]
+	access := f.access
+	if access != "" {
+		access = ", " + access
+	}
+	return fmt.Sprintf(`{"user_id": {"value": %q}, "staff_information": {"staff": {"value": true}}%s}`, f.userID, access)
+}
+
+// newGroupsClient serves groupFixtures, plus a null profile, from both the
+// attribute search and the user listing.
+func newGroupsClient(t *testing.T) (*person_api.Client, func()) {
+	t.Helper()
+	var users, items []string
+	for i, f := range groupFixtures {
+		profile := groupFixtureProfile(i)
+		users = append(users, fmt.Sprintf(`{"id": {"value": %q}, "profile": %s}`, f.userID, profile))
+		items = append(items, profile)
+	}
+	users = append(users, `{"id": {"value": "null profile"}, "profile": null}`)
+	items = append(items, `null`)
+
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/users/id/all/by_attribute_contains"):
+			fmt.Fprintf(w, `{"users": [%s], "nextPage": null}`, strings.Join(users, ", "))
+		case r.URL.Path == "/v2/users":
+			fmt.Fprintf(w, `{"Items": [%s], "nextPage": null}`, strings.Join(items, ", "))
+		default:
+			t.Errorf("unexpected request for %s", r.URL)
+			http.NotFound(w, r)
+		}
+	})
+	return c, s.Close
+}
+
+func TestGroupScansSkipMissingLevels(t *testing.T) {
+	for i, f := range groupFixtures {
+		var p person_api.Person
+		if err := json.Unmarshal([]byte(groupFixtureProfile(i)), &p); err != nil {
+			t.Fatalf("fixture %q does not decode: %v", f.userID, err)
+		}
+	}
+
+	c, closeServer := newGroupsClient(t)
+	defer closeServer()
+	ctx := context.Background()
+
+	userIDs := func(persons []*person_api.Person) []string {
+		ids := []string{}
+		for _, p := range persons {
+			ids = append(ids, p.UserID.Value)
+		}
+		return ids
+	}
+	want := []string{"member"}
+
+	persons, err := c.GetPersonsInGroups(ctx, []string{"team_a"})
+	if err != nil {
+		t.Fatalf("GetPersonsInGroups failed: %v", err)
+	}
+	if got := userIDs(persons); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPersonsInGroups returned %v, want %v", got, want)
+	}
+
+	persons, err = c.GetPersonsInAllGroups(ctx, []string{"team_a"})
+	if err != nil {
+		t.Fatalf("GetPersonsInAllGroups failed: %v", err)
+	}
+	if got := userIDs(persons); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPersonsInAllGroups returned %v, want %v", got, want)
+	}
+
+	persons, err = c.GetPersonsInGroupsMatching(ctx, []string{"team_*"}, person_api.GroupMatchOptions{Glob: true})
+	if err != nil {
+		t.Fatalf("GetPersonsInGroupsMatching failed: %v", err)
+	}
+	if got := userIDs(persons); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPersonsInGroupsMatching returned %v, want %v", got, want)
+	}
+
+	counts, err := c.ListGroups(ctx)
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	if wantCounts := map[string]int{"team_a": 1}; !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("ListGroups returned %v, want %v", counts, wantCounts)
+	}
+}
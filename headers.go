@@ -0,0 +1,36 @@
+package person_api
+
+import (
+	"context"
+	"net/http"
+)
+
+type headersKey struct{}
+
+// WithHeader returns a context that makes the requests of calls using it
+// carry the header key: value, overriding a default set with
+// WithDefaultHeader. Authorization cannot be set this way and is ignored.
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	headers := http.Header{}
+	if existing, ok := ctx.Value(headersKey{}).(http.Header); ok {
+		headers = existing.Clone()
+	}
+	headers.Set(key, value)
+	return context.WithValue(ctx, headersKey{}, headers)
+}
+
+// applyHeaders sets the default headers of the client and then those of ctx
+// on req.
+func (c *Client) applyHeaders(ctx context.Context, req *http.Request) {
+	for key, values := range c.defaultHeaders {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if headers, ok := ctx.Value(headersKey{}).(http.Header); ok {
+		for key, values := range headers {
+			if key == "Authorization" {
+				continue
+			}
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package person_api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// newTestPerson returns a staff profile numbered i.
+func newTestPerson(i int) *person_api.Person {
+	p := &person_api.Person{}
+	p.UserID.Value = fmt.Sprintf("ad|Mozilla-LDAP|user%d", i)
+	p.UUID.Value = fmt.Sprintf("00000000-0000-4000-8000-%012d", i)
+	p.PrimaryEmail.Value = fmt.Sprintf("user%d@mozilla.com", i)
+	p.PrimaryUsername.Value = fmt.Sprintf("user%d", i)
+	p.Active.Value = true
+	return p
+}
+
+func newTestPersons(n int) []*person_api.Person {
+	persons := make([]*person_api.Person, n)
+	for i := range persons {
+		persons[i] = newTestPerson(i)
+	}
+	return persons
+}
+
+// newStubClient starts a server answering with handler and returns a
+// client for it holding a static token. Close the server when done.
+func newStubClient(t testing.TB, handler http.HandlerFunc, opts ...person_api.Option) (*person_api.Client, *httptest.Server) {
+	t.Helper()
+	s := httptest.NewServer(handler)
+	c, err := person_api.NewClientWithToken("token", s.URL, opts...)
+	if err != nil {
+		s.Close()
+		t.Fatalf("NewClientWithToken failed: %v", err)
+	}
+	return c, s
+}
+
+// recordingLogger keeps every message it receives as a line of text, with
+// the level, message and keys and values separated by spaces.
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) record(level, msg string, keysAndValues []interface{}) {
+	fields := []string{level, msg}
+	for _, v := range keysAndValues {
+		fields = append(fields, fmt.Sprint(v))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, strings.Join(fields, " "))
+}
+
+func (l *recordingLogger) Debug(msg string, kv ...interface{}) { l.record("DEBUG", msg, kv) }
+func (l *recordingLogger) Info(msg string, kv ...interface{})  { l.record("INFO", msg, kv) }
+func (l *recordingLogger) Warn(msg string, kv ...interface{})  { l.record("WARN", msg, kv) }
+func (l *recordingLogger) Error(msg string, kv ...interface{}) { l.record("ERROR", msg, kv) }
+
+// Lines returns the lines logged so far.
+func (l *recordingLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines...)
+}
@@ -0,0 +1,123 @@
+package person_api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// httpTransportOption changes the *http.Transport of the client's
+// http.Client, see configureHTTPTransport.
+type httpTransportOption func(*http.Transport)
+
+// configureHTTPTransport applies the transport options to a copy of the
+// http.Client and its transport, so a client passed to WithHTTPClient and
+// http.DefaultTransport are never modified. HTTP/2 is disabled last, so that
+// no later option such as WithTLSConfig offers it again. It fails if the
+// http.Client uses a transport other than *http.Transport.
+func (c *Client) configureHTTPTransport() error {
+	if len(c.transportOptions) == 0 && !c.disableHTTP2 {
+		return nil
+	}
+	var base *http.Transport
+	switch rt := c.httpClient.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = rt
+	default:
+		return fmt.Errorf("Transport options need an *http.Transport, the HTTP client uses %T", rt)
+	}
+	transport := base.Clone()
+	for _, opt := range c.transportOptions {
+		opt(transport)
+	}
+	if c.disableHTTP2 {
+		disableHTTP2(transport)
+	}
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
+
+// TransportTuning configures the connection handling of the transport, see
+// WithTransportTuning. Zero fields keep the net/http defaults noted below.
+type TransportTuning struct {
+	// MaxIdleConns bounds the idle connections kept across all hosts,
+	// 100 by default.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost bounds the idle connections kept per host, 2 by
+	// default, which is too few for concurrent lookups and makes them open
+	// a new connection for most requests.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer, 90 seconds by
+	// default.
+	IdleConnTimeout time.Duration
+	// DisableHTTP2 restricts the client to HTTP/1.1, which is otherwise
+	// negotiated with servers supporting HTTP/2.
+	DisableHTTP2 bool
+	// DialTimeout bounds establishing a TCP connection, 30 seconds by
+	// default.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake, 10 seconds by default.
+	TLSHandshakeTimeout time.Duration
+}
+
+// WithTransportTuning tunes the connection pool and timeouts of the
+// transport used for the auth and API requests. For bulk lookups, raise
+// MaxIdleConnsPerHost to the number of concurrent requests. It requires the
+// HTTP client, see WithHTTPClient, to use an *http.Transport.
+func WithTransportTuning(tuning TransportTuning) Option {
+	return func(c *Client) error {
+		if tuning.MaxIdleConns < 0 || tuning.MaxIdleConnsPerHost < 0 {
+			return fmt.Errorf("Idle connection limits must not be negative")
+		}
+		if tuning.IdleConnTimeout < 0 || tuning.DialTimeout < 0 || tuning.TLSHandshakeTimeout < 0 {
+			return fmt.Errorf("Transport timeouts must not be negative")
+		}
+		c.transportOptions = append(c.transportOptions, tuning.apply)
+		if tuning.DisableHTTP2 {
+			c.disableHTTP2 = true
+		}
+		return nil
+	}
+}
+
+func (tuning TransportTuning) apply(t *http.Transport) {
+	if tuning.MaxIdleConns > 0 {
+		t.MaxIdleConns = tuning.MaxIdleConns
+	}
+	if tuning.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = tuning.MaxIdleConnsPerHost
+	}
+	if tuning.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = tuning.IdleConnTimeout
+	}
+	if tuning.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: tuning.DialTimeout, KeepAlive: 30 * time.Second}
+		t.DialContext = dialer.DialContext
+	}
+	if tuning.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = tuning.TLSHandshakeTimeout
+	}
+}
+
+// disableHTTP2 restricts t to HTTP/1.1. A non-nil empty TLSNextProto keeps
+// net/http from enabling HTTP/2, and h2 must not be offered in the handshake
+// either.
+func disableHTTP2(t *http.Transport) {
+	t.ForceAttemptHTTP2 = false
+	t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	if t.TLSClientConfig != nil {
+		var protos []string
+		for _, proto := range t.TLSClientConfig.NextProtos {
+			if proto != "h2" {
+				protos = append(protos, proto)
+			}
+		}
+		t.TLSClientConfig.NextProtos = protos
+	}
+}
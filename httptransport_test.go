@@ -0,0 +1,110 @@
+package person_api_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+func TestDisableHTTP2(t *testing.T) {
+	var proto atomic.Value
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto.Store(r.Proto)
+		w.Write([]byte(`{}`))
+	}))
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	defer s.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	// The TLS config offers h2, as configs built for HTTP/2 clients do.
+	tlsConfig := person_api.WithTLSConfig(&tls.Config{RootCAs: pool, NextProtos: []string{"h2", "http/1.1"}})
+	tuning := person_api.WithTransportTuning(person_api.TransportTuning{DisableHTTP2: true})
+
+	tests := []struct {
+		name string
+		opts []person_api.Option
+		want string
+	}{
+		{"enabled", []person_api.Option{tlsConfig}, "HTTP/2.0"},
+		{"disabled before WithTLSConfig", []person_api.Option{tuning, tlsConfig}, "HTTP/1.1"},
+		{"disabled after WithTLSConfig", []person_api.Option{tlsConfig, tuning}, "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := person_api.NewClientWithToken("token", s.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClientWithToken failed: %v", err)
+			}
+			defer c.Close()
+
+			if err := c.Ping(context.Background()); err != nil {
+				t.Fatalf("Ping failed: %v", err)
+			}
+			if got := proto.Load(); got != tt.want {
+				t.Errorf("request used %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkConcurrentLookups runs 100 concurrent lookups per iteration and
+// reports the connections they open, which MaxIdleConnsPerHost lets the
+// following iterations reuse.
+func BenchmarkConcurrentLookups(b *testing.B) {
+	const concurrency = 100
+	profile, err := person_api.MarshalPerson(*newTestPerson(1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	tunings := map[string]person_api.TransportTuning{
+		"default":        {},
+		"idle conns 100": {MaxIdleConnsPerHost: concurrency},
+	}
+	for name, tuning := range tunings {
+		b.Run(name, func(b *testing.B) {
+			var conns int64
+			s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(profile)
+			}))
+			s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt64(&conns, 1)
+				}
+			}
+			s.Start()
+			defer s.Close()
+			c, err := person_api.NewClientWithToken("token", s.URL, person_api.WithTransportTuning(tuning))
+			if err != nil {
+				b.Fatalf("NewClientWithToken failed: %v", err)
+			}
+			defer c.Close()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for j := 0; j < concurrency; j++ {
+					wg.Add(1)
+					go func(j int) {
+						defer wg.Done()
+						if _, err := c.GetPersonByUserId(context.Background(), fmt.Sprintf("ad|Mozilla-LDAP|user%d", j)); err != nil {
+							b.Error(err)
+						}
+					}(j)
+				}
+				wg.Wait()
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&conns))/float64(b.N), "conns/op")
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package person_api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+	"go.mozilla.org/person-api/personapitest"
+)
+
+func TestGetPersonsModifiedSinceResumes(t *testing.T) {
+	persons := newTestPersons(10)
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, p := range persons {
+		p.LastModified.Value = since.Add(time.Duration(i-5) * time.Hour).Format(time.RFC3339)
+	}
+	noRetries := person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1})
+
+	failing := personapitest.NewServer(t, persons)
+	defer failing.Close()
+	failing.PageSize = 3
+	failing.FailPage = 3
+	c, err := failing.NewClient(noRetries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modified, err := c.GetPersonsModifiedSince(context.Background(), since)
+	var syncErr *person_api.SyncError
+	if !errors.As(err, &syncErr) {
+		t.Fatalf("GetPersonsModifiedSince returned %v, want a *SyncError", err)
+	}
+	if syncErr.Cursor == "" {
+		t.Error("SyncError has no cursor for the third page")
+	}
+	if len(modified) != 1 {
+		t.Errorf("GetPersonsModifiedSince returned %d persons before failing, want 1", len(modified))
+	}
+
+	healthy := personapitest.NewServer(t, persons)
+	defer healthy.Close()
+	healthy.PageSize = 3
+	c, err = healthy.NewClient(noRetries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rest, err := c.GetPersonsModifiedSinceWithOptions(context.Background(), since, person_api.ModifiedSinceOptions{Cursor: syncErr.Cursor})
+	if err != nil {
+		t.Fatalf("resuming failed: %v", err)
+	}
+	var got []string
+	for _, p := range append(modified, rest...) {
+		got = append(got, p.UserID.Value)
+	}
+	if len(got) != 5 {
+		t.Fatalf("the resumed sync returned %v, want the 5 persons modified since %s", got, since)
+	}
+	for i, id := range got {
+		if want := persons[5+i].UserID.Value; id != want {
+			t.Errorf("person %d is %s, want %s", i, id, want)
+		}
+	}
+}
+
+func TestGetPersonsModifiedSinceLimitResumes(t *testing.T) {
+	persons := newTestPersons(10)
+	s := personapitest.NewServer(t, persons)
+	defer s.Close()
+	s.PageSize = 3
+	c, err := s.NewClient(person_api.WithMaxPages(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modified, err := c.GetPersonsModifiedSince(context.Background(), time.Time{})
+	var syncErr *person_api.SyncError
+	if !errors.As(err, &syncErr) {
+		t.Fatalf("GetPersonsModifiedSince returned %v, want a *SyncError", err)
+	}
+	var limitErr *person_api.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "pages" {
+		t.Errorf("GetPersonsModifiedSince returned %v, want a page *LimitError", err)
+	}
+	if syncErr.Cursor == "" {
+		t.Error("SyncError has no cursor for the page over the limit")
+	}
+	// The page whose next cursor is over the limit is dropped and fetched
+	// again on resumption.
+	if len(modified) != 3 {
+		t.Errorf("GetPersonsModifiedSince returned %d persons before the limit, want 3", len(modified))
+	}
+
+	c, err = s.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, err := c.GetPersonsModifiedSinceWithOptions(context.Background(), time.Time{}, person_api.ModifiedSinceOptions{Cursor: syncErr.Cursor})
+	if err != nil {
+		t.Fatalf("resuming failed: %v", err)
+	}
+	all := append(modified, rest...)
+	if len(all) != len(persons) {
+		t.Fatalf("the resumed sync returned %d persons, want %d", len(all), len(persons))
+	}
+	for i, p := range all {
+		if want := persons[i].UserID.Value; p.UserID.Value != want {
+			t.Errorf("person %d is %s, want %s", i, p.UserID.Value, want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package person_api
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errInvalidSignature is wrapped by every JWS verification failure.
+var errInvalidSignature = errors.New("invalid signature")
+
+type jwsHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// jwsKeyFunc selects the public key for the header of a JWS.
+type jwsKeyFunc func(header jwsHeader) (crypto.PublicKey, error)
+
+// verifyJWS checks the signature of a compact serialized JWS and returns its
+// decoded payload. Supported algorithms are RS256 and EdDSA (Ed25519).
+func verifyJWS(token string, keyFunc jwsKeyFunc) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("Malformed JWS: expected 3 segments, got %d", len(parts))
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("Malformed JWS header: %w", err)
+	}
+	var header jwsHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return nil, fmt.Errorf("Malformed JWS header: %w", err)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("Malformed JWS payload: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("Malformed JWS signature: %w", err)
+	}
+	key, err := keyFunc(header)
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := verifySignature(header.Alg, key, signed, sig); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	switch alg {
+	case "RS256":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: RS256 needs an RSA key, got %T", errInvalidSignature, key)
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidSignature, err)
+		}
+		return nil
+	case "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: EdDSA needs an Ed25519 key, got %T", errInvalidSignature, key)
+		}
+		if !ed25519.Verify(k, signed, sig) {
+			return errInvalidSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", errInvalidSignature, alg)
+	}
+}
+
+// jwk is the subset of RFC 7517 needed for RSA and Ed25519 public keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed RSA modulus of key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed RSA exponent of key %q: %w", k.Kid, err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 2 || exp.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("Invalid RSA key %q", k.Kid)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("Unsupported curve %q of key %q", k.Crv, k.Kid)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("Malformed Ed25519 key %q", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("Unsupported key type %q of key %q", k.Kty, k.Kid)
+	}
+}
+
+// parseJWKS decodes a JSON Web Key Set, skipping keys not meant for
+// signatures and keys of unsupported types.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("Malformed JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// maxJWKSSize bounds the key set documents read by jwksCache.
+const maxJWKSSize = 1 << 20
+
+// jwksCache fetches a JWKS lazily and keeps it for ttl. A token signed with
+// an unknown key id triggers a refetch, at most once per minRefresh, so key
+// rotations are picked up without hammering the IdP. Concurrent callers
+// share a single fetch, and after a failed fetch the IdP is left alone for
+// minRefresh, during which the keys fetched before keep being used.
+type jwksCache struct {
+	url        string
+	httpClient *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	// mu guards the fields below and is never held across a fetch.
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	fetched  time.Time
+	fetching *jwksFetch
+	retryAt  time.Time
+	fetchErr error
+}
+
+// jwksFetch is a JWKS request shared by everyone waiting for the keys.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+func (j *jwksCache) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	keys, fetched := j.current()
+	if keys == nil || time.Since(fetched) > j.ttl {
+		if err := j.refetch(ctx); err != nil && keys == nil {
+			return nil, err
+		}
+		keys, fetched = j.current()
+	}
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(fetched) > j.minRefresh {
+		if err := j.refetch(ctx); err != nil {
+			return nil, err
+		}
+		keys, _ = j.current()
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown key id %q", errInvalidSignature, kid)
+}
+
+func (j *jwksCache) current() (map[string]crypto.PublicKey, time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.keys, j.fetched
+}
+
+// refetch joins the fetch in flight or starts one. Within minRefresh of a
+// failed fetch it returns that failure instead. Like Client.refresh, the
+// fetch runs detached from ctx so that a caller giving up does not fail the
+// others; it is bounded by DefaultTimeout.
+func (j *jwksCache) refetch(ctx context.Context) error {
+	j.mu.Lock()
+	if time.Now().Before(j.retryAt) {
+		err := j.fetchErr
+		j.mu.Unlock()
+		return err
+	}
+	call := j.fetching
+	if call == nil {
+		call = &jwksFetch{done: make(chan struct{})}
+		j.fetching = call
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+			defer cancel()
+			keys, err := j.fetch(ctx)
+			j.mu.Lock()
+			if err != nil {
+				j.retryAt, j.fetchErr = time.Now().Add(j.minRefresh), err
+			} else {
+				j.keys, j.fetched = keys, time.Now()
+			}
+			j.fetching = nil
+			j.mu.Unlock()
+			call.err = err
+			close(call.done)
+		}()
+	}
+	j.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch requests the key set; use refetch instead.
+func (j *jwksCache) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Fetching JWKS from %s failed: %w", j.url, err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, newAPIError(resp)
+	}
+	defer drainAndClose(resp.Body)
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
@@ -0,0 +1,20 @@
+package person_api
+
+// Logger receives structured log messages from a client, see WithLogger.
+// keysAndValues alternate between string keys and arbitrary values. The
+// client never passes access tokens, client secrets or Authorization
+// headers, and identifies requests by their normalized endpoint so that
+// emails or user ids in lookup URLs are not logged either.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
@@ -0,0 +1,151 @@
+package person_api_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// TestLogsOmitLookupIdentifiers fails lookups with transport errors and
+// error responses, and checks that no log line names the person looked up.
+func TestLogsOmitLookupIdentifiers(t *testing.T) {
+	const secret = "jane.secret"
+	handlers := map[string]http.HandlerFunc{
+		"transport error": func(w http.ResponseWriter, r *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("Hijack failed: %v", err)
+				return
+			}
+			conn.Close()
+		},
+		"server error": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		},
+		"not found": func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		},
+	}
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			c, s := newStubClient(t, handler,
+				person_api.WithLogger(logger),
+				person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+			defer s.Close()
+			ctx := context.Background()
+
+			if _, err := c.GetPersonByEmail(ctx, secret+"@mozilla.com"); err == nil {
+				t.Error("GetPersonByEmail succeeded")
+			}
+			if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|"+secret); err == nil {
+				t.Error("GetPersonByUserId succeeded")
+			}
+
+			lines := logger.Lines()
+			if len(lines) == 0 {
+				t.Fatal("nothing was logged")
+			}
+			for _, line := range lines {
+				if strings.Contains(line, secret) {
+					t.Errorf("log line names the person looked up: %s", line)
+				}
+			}
+		})
+	}
+}
+
+// TestLogsOmitCredentials fetches a token, refreshes it after a 401, fails a
+// token request and retries a 5xx, all with servers echoing what they
+// received, and checks that no log line holds the client secret, an access
+// token or an Authorization header.
+func TestLogsOmitCredentials(t *testing.T) {
+	const (
+		secret      = "client-secret-7c1e9b3f"
+		tokenSuffix = "a9d4e2f6b8c0"
+	)
+	var tokenRequests, minToken, retried int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/oauth/token" {
+			n := atomic.AddInt64(&tokenRequests, 1)
+			if n == 3 {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprintf(w, `{"error": "access_denied", "error_description": %q}`, "Unauthorized: "+string(body))
+				return
+			}
+			fmt.Fprintf(w, `{"access_token": "token-%d-%s", "expires_in": 3600, "token_type": "Bearer"}`, n, tokenSuffix)
+			return
+		}
+		authorization := r.Header.Get("Authorization")
+		var n int64
+		fmt.Sscanf(authorization, "Bearer token-%d-", &n)
+		if n < atomic.LoadInt64(&minToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"message": "Unauthorized", "authorization": %q}`, authorization)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "retried") && atomic.AddInt64(&retried, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, `{"message": "unavailable", "authorization": %q}`, authorization)
+			return
+		}
+		fmt.Fprint(w, `{"user_id": {"value": "ad|Mozilla-LDAP|user"}}`)
+	}))
+	defer s.Close()
+
+	logger := &recordingLogger{}
+	c, err := person_api.NewClient("id", secret,
+		person_api.WithBaseURL(s.URL),
+		person_api.WithAuthURL(s.URL+"/oauth/token"),
+		person_api.WithLogger(logger),
+		person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	// The first token is rejected, so the lookup refreshes it and retries.
+	atomic.StoreInt64(&minToken, 2)
+	if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|refreshed"); err != nil {
+		t.Errorf("lookup after a refresh failed: %v", err)
+	}
+	if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|retried"); err != nil {
+		t.Errorf("lookup after a retried 5xx failed: %v", err)
+	}
+	// The second token is rejected and the third token request fails.
+	atomic.StoreInt64(&minToken, 3)
+	if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|rejected"); err == nil {
+		t.Error("lookup with a failing token request succeeded")
+	}
+	if got := atomic.LoadInt64(&tokenRequests); got != 3 {
+		t.Errorf("made %d token requests, want 3", got)
+	}
+
+	lines := logger.Lines()
+	for _, want := range []string{"Refreshed the access token", "Retrying request", "Refreshing the access token failed"} {
+		found := false
+		for _, line := range lines {
+			found = found || strings.Contains(line, want)
+		}
+		if !found {
+			t.Errorf("no log line contains %q, logged %q", want, lines)
+		}
+	}
+	for _, line := range lines {
+		for _, leak := range []string{secret, tokenSuffix, "Bearer "} {
+			if strings.Contains(line, leak) {
+				t.Errorf("log line contains %q: %s", leak, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package person_api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// nullProfile returns testdata/valid/contributor.json with the value of
+// every attribute set to null, the shape of the profile the API returns for
+// an unknown person.
+func nullProfile(t *testing.T) string {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/valid/contributor.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profile map[string]interface{}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		t.Fatal(err)
+	}
+	var nullify func(attrs map[string]interface{})
+	nullify = func(attrs map[string]interface{}) {
+		for _, v := range attrs {
+			attr, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			_, hasValue := attr["value"]
+			_, hasValues := attr["values"]
+			switch {
+			case hasValue:
+				attr["value"] = nil
+			case hasValues:
+				attr["values"] = nil
+			default:
+				nullify(attr)
+			}
+		}
+	}
+	nullify(profile)
+	data, err = json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLookupNotFound(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"404", http.StatusNotFound, `{"message": "Not found"}`},
+		{"empty object", http.StatusOK, `{}`},
+		{"null attributes", http.StatusOK, `{"user_id": null, "uuid": null, "primary_email": null, "primary_username": null}`},
+		{"null values", http.StatusOK, `{"user_id": {"value": null}, "uuid": {"value": null}, "primary_email": {"value": null}}`},
+		{"empty values", http.StatusOK, `{"user_id": {"value": ""}, "uuid": {"value": ""}, "primary_email": {"value": ""}}`},
+		{"every attribute null", http.StatusOK, nullProfile(t)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+			defer s.Close()
+
+			p, err := c.GetPersonByEmail(context.Background(), "unknown@mozilla.com")
+			if p != nil || !errors.Is(err, person_api.ErrNotFound) {
+				t.Errorf("GetPersonByEmail = %v, %v, want ErrNotFound", p, err)
+			}
+		})
+	}
+}
+
+func TestLookupServerErrorIsNotNotFound(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}, person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1}))
+	defer s.Close()
+
+	_, err := c.GetPersonByEmail(context.Background(), "user@mozilla.com")
+	var apiErr *person_api.APIError
+	if errors.Is(err, person_api.ErrNotFound) || !errors.As(err, &apiErr) {
+		t.Errorf("GetPersonByEmail = %v, want an *APIError other than ErrNotFound", err)
+	}
+}
+
+func TestLookupEscapesIdentifiers(t *testing.T) {
+	ids := []string{
+		"jane+test@example.com",
+		"ad|Mozilla-LDAP|a/b",
+		"zoë@例え.jp",
+		"100%real@example.com",
+		"hash#tag?query",
+	}
+	lookups := map[string]func(*person_api.Client, context.Context, string) (*person_api.Person, error){
+		"primary_email":    (*person_api.Client).GetPersonByEmail,
+		"user_id":          (*person_api.Client).GetPersonByUserId,
+		"uuid":             (*person_api.Client).GetPersonByUUID,
+		"primary_username": (*person_api.Client).GetPersonByUsername,
+	}
+	for field, lookup := range lookups {
+		for _, id := range ids {
+			var gotField, gotId string
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				segments := strings.Split(r.URL.EscapedPath(), "/")
+				if len(segments) != 5 {
+					t.Errorf("Lookup of %q requested %s, want /v2/user/<field>/<id>", id, r.URL.EscapedPath())
+					return
+				}
+				gotField = segments[3]
+				// The API decodes plus signs as spaces, like a query.
+				gotId, _ = url.QueryUnescape(segments[4])
+				io.WriteString(w, `{"user_id": {"value": "ad|Mozilla-LDAP|user"}}`)
+			})
+			if _, err := lookup(c, context.Background(), id); err != nil {
+				t.Errorf("Lookup by %s of %q failed: %v", field, id, err)
+			}
+			if gotField != field || gotId != id {
+				t.Errorf("Lookup by %s of %q arrived as %s %q", field, id, gotField, gotId)
+			}
+			s.Close()
+		}
+	}
+}
+
+func TestLookupRejectsEmptyIdentifier(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Lookup of an empty identifier requested %s", r.URL)
+	})
+	defer s.Close()
+
+	if _, err := c.GetPersonByEmail(context.Background(), ""); err == nil {
+		t.Error("GetPersonByEmail(\"\") succeeded, want an error")
+	}
+}
@@ -0,0 +1,446 @@
+package person_api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseURL  = "https://person.api.sso.mozilla.com"
+	DefaultAuthURL  = "https://auth.mozilla.auth0.com/oauth/token"
+	DefaultAudience = "api.sso.mozilla.com"
+
+	// DefaultTimeout bounds each request made with the default HTTP
+	// client. Clients passed to WithHTTPClient keep their own timeout.
+	DefaultTimeout      = 30 * time.Second
+	DefaultExpiryMargin = 60 * time.Second
+	// DefaultEnumerationTimeout bounds enumerations such as GetAllUsers
+	// which issue one request per page.
+	DefaultEnumerationTimeout = 30 * time.Minute
+	// DefaultMaxPages and DefaultMaxUsers bound a single enumeration, well
+	// above the size of the directory, so that a misbehaving cursor fails
+	// instead of exhausting memory.
+	DefaultMaxPages = 100000
+	DefaultMaxUsers = 5000000
+)
+
+// Option configures a Client in NewClient. Options are applied in order and
+// an invalid option makes NewClient fail before any request is made.
+type Option func(*Client) error
+
+// WithBaseURL sets the URL of the Person API, DefaultBaseURL by default. It
+// must be an http or https URL; trailing slashes are dropped.
+func WithBaseURL(baseUrl string) Option {
+	return func(c *Client) error {
+		normalized, err := normalizeURL("Base URL", baseUrl)
+		if err != nil {
+			return err
+		}
+		c.baseUrl = normalized
+		return nil
+	}
+}
+
+// WithAuthURL sets the token endpoint, DefaultAuthURL by default, with the
+// same requirements as WithBaseURL.
+func WithAuthURL(authUrl string) Option {
+	return func(c *Client) error {
+		normalized, err := normalizeURL("Auth URL", authUrl)
+		if err != nil {
+			return err
+		}
+		c.authUrl = normalized
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http.Client used for both the auth and the API
+// requests, for example to configure a proxy or custom CA bundle.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return fmt.Errorf("HTTP client must not be nil")
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request. When combined with
+// WithHTTPClient the supplied client is copied rather than modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("Timeout must be positive, got %s", timeout)
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithUserAgent replaces DefaultUserAgent on the token and API requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) error {
+		if userAgent == "" {
+			return fmt.Errorf("User agent must not be empty")
+		}
+		c.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithUserAgentSuffix appends suffix, such as "my-service/1.2", to the
+// User-Agent so traffic can be attributed to a service while still
+// identifying this client. The suffix is appended to DefaultUserAgent, or to
+// the value of WithUserAgent regardless of the order of the options.
+func WithUserAgentSuffix(suffix string) Option {
+	return func(c *Client) error {
+		if suffix == "" {
+			return fmt.Errorf("User agent suffix must not be empty")
+		}
+		c.userAgentSuffix += " " + suffix
+		return nil
+	}
+}
+
+// WithAudience sets the audience of the access token, which differs per
+// deployment of the Person API.
+func WithAudience(audience string) Option {
+	return func(c *Client) error {
+		if audience == "" {
+			return fmt.Errorf("Audience must not be empty")
+		}
+		c.audience = audience
+		return nil
+	}
+}
+
+// WithScopes sets the scopes requested with the access token.
+func WithScopes(scopes ScopeSet) Option {
+	return func(c *Client) error {
+		if len(scopes) == 0 {
+			return fmt.Errorf("At least one scope is required")
+		}
+		for _, s := range scopes {
+			if s == "" || strings.ContainsAny(string(s), " \t\n") {
+				return fmt.Errorf("Invalid scope %q", s)
+			}
+		}
+		c.scope = scopes.String()
+		return nil
+	}
+}
+
+// WithExpiryMargin sets how long before the reported expiry the access token
+// is proactively refreshed.
+func WithExpiryMargin(margin time.Duration) Option {
+	return func(c *Client) error {
+		if margin < 0 {
+			return fmt.Errorf("Expiry margin must not be negative, got %s", margin)
+		}
+		c.expiryMargin = margin
+		return nil
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy for API and auth requests.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		c.retryPolicy = policy
+		return nil
+	}
+}
+
+// WithEnumerationTimeout sets the overall deadline of enumerations spanning
+// several pages, such as GetAllUsers and StreamAllUsers.
+func WithEnumerationTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("Enumeration timeout must be positive, got %s", timeout)
+		}
+		c.enumTimeout = timeout
+		return nil
+	}
+}
+
+// WithMaxPages limits how many pages a single enumeration may fetch before
+// failing with a *LimitError.
+func WithMaxPages(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("Max pages must be positive, got %d", n)
+		}
+		c.maxPages = n
+		return nil
+	}
+}
+
+// WithMaxUsers limits how many users a single enumeration may return before
+// failing with a *LimitError.
+func WithMaxUsers(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("Max users must be positive, got %d", n)
+		}
+		c.maxUsers = n
+		return nil
+	}
+}
+
+// WithProgress calls fn after every page fetched by an enumeration, such as
+// GetAllUsers, StreamAllUsers, ForEachUser or GetPersonsInGroups, with the
+// number of users and pages fetched by that enumeration so far. fn runs on
+// the enumerating goroutine without any lock of the client held, so it may
+// use the client, but it delays the next page until it returns.
+func WithProgress(fn func(fetchedUsers, pages int)) Option {
+	return func(c *Client) error {
+		if fn == nil {
+			return fmt.Errorf("Progress callback must not be nil")
+		}
+		c.progress = fn
+		return nil
+	}
+}
+
+// WithSignatureVerification makes the GetPersonBy... lookups verify the
+// attribute signatures of each profile with keys and fail with a
+// *SignatureError if any do not verify. Enumerations are not verified since
+// doing so is CPU heavy; call VerifySignatures on their results instead.
+func WithSignatureVerification(keys PublisherKeys) Option {
+	return func(c *Client) error {
+		if len(keys) == 0 {
+			return fmt.Errorf("Signature verification needs at least one publisher key")
+		}
+		c.verifyKeys = keys
+		return nil
+	}
+}
+
+// WithStrictDecoding makes the GetPersonBy... lookups and the GetAllUsers
+// family fail with a *StrictDecodingError on profiles with fields Person
+// does not model, instead of silently dropping them. It is meant for
+// catching schema drift in tests and canaries.
+func WithStrictDecoding() Option {
+	return func(c *Client) error {
+		c.strictDecoding = true
+		return nil
+	}
+}
+
+// WithTokenCache makes NewClient start with a cached token that is still
+// valid beyond the expiry margin instead of requesting one, and stores every
+// newly issued token in cache. A cached token the API rejects with 401 is
+// replaced as usual.
+func WithTokenCache(cache TokenCache) Option {
+	return func(c *Client) error {
+		if cache == nil {
+			return fmt.Errorf("Token cache must not be nil")
+		}
+		c.tokenCache = cache
+		return nil
+	}
+}
+
+// WithResponseCache makes person lookups send the ETag of a cached profile
+// as If-None-Match and reuse the cached profile when the API answers 304 Not
+// Modified. Profiles the API sends no ETag for are not cached. Use
+// NewMemoryResponseCache for an in-memory cache and WithoutResponseCache to
+// bypass it for a call.
+func WithResponseCache(cache ResponseCache) Option {
+	return func(c *Client) error {
+		if cache == nil {
+			return fmt.Errorf("Response cache must not be nil")
+		}
+		c.responseCache = cache
+		return nil
+	}
+}
+
+// WithPersonCache caches the profiles returned by the GetPersonBy* lookups
+// for ttl, keeping at most maxEntries of them. Lookups are cached per field
+// and identifier, see InvalidatePerson. A StatsRecorder implementing
+// PersonCacheRecorder observes the hits and misses.
+func WithPersonCache(ttl time.Duration, maxEntries int) Option {
+	return func(c *Client) error {
+		if ttl <= 0 {
+			return fmt.Errorf("Person cache TTL must be positive")
+		}
+		if maxEntries <= 0 {
+			return fmt.Errorf("Person cache size must be positive")
+		}
+		c.personCache = newPersonCache(ttl, maxEntries)
+		return nil
+	}
+}
+
+// WithNegativePersonCache makes the cache installed by WithPersonCache also
+// remember lookups that found no person, for ttl, which is usually shorter
+// than the TTL of profiles. NewClient fails without WithPersonCache, which
+// may be given before or after this option.
+func WithNegativePersonCache(ttl time.Duration) Option {
+	return func(c *Client) error {
+		if ttl <= 0 {
+			return fmt.Errorf("Negative person cache TTL must be positive")
+		}
+		c.negativeTTL = ttl
+		return nil
+	}
+}
+
+// WithAutoRefresh starts a goroutine refreshing the access token margin
+// before it expires, so requests rarely wait for the auth server. Close
+// stops it. Only clients with credentials can refresh, so the other
+// constructors reject this option.
+func WithAutoRefresh(margin time.Duration) Option {
+	return func(c *Client) error {
+		if margin <= 0 {
+			return fmt.Errorf("Auto refresh margin must be positive, got %s", margin)
+		}
+		c.autoRefresh = margin
+		return nil
+	}
+}
+
+// WithRateLimit caps the requests of the client, including retries, pages
+// and token requests, at rps per second with bursts of up to burst
+// requests. Requests over the budget wait, or fail with the context error
+// if their deadline would pass first.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) error {
+		if rps <= 0 || burst < 1 {
+			return fmt.Errorf("Rate limit needs a positive rate and burst, got %g and %d", rps, burst)
+		}
+		c.limiter = newRateLimiter(rps, burst)
+		return nil
+	}
+}
+
+// WithCircuitBreaker makes the client fail fast with ErrCircuitOpen after
+// threshold consecutive requests failed with a transport error or a 5xx
+// status. After cooldown a single probe request is let through; its
+// success closes the circuit again, its failure restarts the cooldown.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) error {
+		if threshold < 1 || cooldown <= 0 {
+			return fmt.Errorf("Circuit breaker needs a positive threshold and cooldown, got %d and %s", threshold, cooldown)
+		}
+		c.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown}
+		return nil
+	}
+}
+
+// WithCircuitStateHook calls fn on every state transition of the circuit
+// breaker, for example to alert when it opens. fn must not block.
+func WithCircuitStateHook(fn func(from, to CircuitState)) Option {
+	return func(c *Client) error {
+		if fn == nil {
+			return fmt.Errorf("Circuit state hook must not be nil")
+		}
+		c.circuitHook = fn
+		return nil
+	}
+}
+
+// WithStats reports every request and token refresh to recorder.
+func WithStats(recorder StatsRecorder) Option {
+	return func(c *Client) error {
+		if recorder == nil {
+			return fmt.Errorf("Stats recorder must not be nil")
+		}
+		c.stats = recorder
+		return nil
+	}
+}
+
+// WithTracer traces lookups and enumerations with tracer, with a child span
+// per request, i.e. per page of an enumeration.
+func WithTracer(tracer Tracer) Option {
+	return func(c *Client) error {
+		if tracer == nil {
+			return fmt.Errorf("Tracer must not be nil")
+		}
+		c.tracer = tracer
+		return nil
+	}
+}
+
+// WithLogger logs token refreshes, retries, rate limiter waits, fetched
+// pages and error responses to logger. Nothing is logged by default.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return fmt.Errorf("Logger must not be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithDebugTransport writes every request and response, including the
+// token requests, to w. Authorization headers, client secrets and issued
+// tokens are replaced by REDACTED and bodies are truncated, see
+// WithDebugBodyLimit. Responses are buffered in full to be dumped.
+func WithDebugTransport(w io.Writer) Option {
+	return func(c *Client) error {
+		if w == nil {
+			return fmt.Errorf("Debug writer must not be nil")
+		}
+		c.debugWriter = w
+		return nil
+	}
+}
+
+// WithDebugBodyLimit sets how many bytes of each body WithDebugTransport
+// writes, DefaultDebugBodyLimit by default.
+func WithDebugBodyLimit(limit int) Option {
+	return func(c *Client) error {
+		if limit < 0 {
+			return fmt.Errorf("Debug body limit must not be negative, got %d", limit)
+		}
+		c.debugBodyLimit = limit
+		return nil
+	}
+}
+
+// WithTransportMiddleware inserts layers into the chain each request passes
+// through, with the first middleware outermost. The chain adds the bearer
+// token, then runs the middlewares, the retries, the rate limiter, the
+// circuit breaker, the stats and the debug dump before handing the request
+// to the HTTP client. Middlewares thus see each logical request once, token
+// requests included. Giving the option more than once appends.
+func WithTransportMiddleware(middlewares ...func(http.RoundTripper) http.RoundTripper) Option {
+	return func(c *Client) error {
+		for _, m := range middlewares {
+			if m == nil {
+				return fmt.Errorf("Transport middleware must not be nil")
+			}
+		}
+		c.middlewares = append(c.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// WithDefaultHeader sets the header key: value on every request, e.g. a
+// header required by a gateway. WithHeader overrides it for single calls.
+// Authorization is managed by the client and rejected.
+func WithDefaultHeader(key, value string) Option {
+	return func(c *Client) error {
+		if key == "" {
+			return fmt.Errorf("Header name must not be empty")
+		}
+		if http.CanonicalHeaderKey(key) == "Authorization" {
+			return fmt.Errorf("The Authorization header cannot be set as a default header")
+		}
+		if c.defaultHeaders == nil {
+			c.defaultHeaders = http.Header{}
+		}
+		c.defaultHeaders.Set(key, value)
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package person_api_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []person_api.Option
+		want string
+	}{
+		{"default", nil, person_api.DefaultUserAgent},
+		{
+			"suffix",
+			[]person_api.Option{person_api.WithUserAgentSuffix("svc/1")},
+			person_api.DefaultUserAgent + " svc/1",
+		},
+		{
+			"suffixes",
+			[]person_api.Option{person_api.WithUserAgentSuffix("svc/1"), person_api.WithUserAgentSuffix("job/2")},
+			person_api.DefaultUserAgent + " svc/1 job/2",
+		},
+		{
+			"suffix after user agent",
+			[]person_api.Option{person_api.WithUserAgent("agent/3"), person_api.WithUserAgentSuffix("svc/1")},
+			"agent/3 svc/1",
+		},
+		{
+			"suffix before user agent",
+			[]person_api.Option{person_api.WithUserAgentSuffix("svc/1"), person_api.WithUserAgent("agent/3")},
+			"agent/3 svc/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				got = r.UserAgent()
+				w.Write([]byte(`{"user_id": {"value": "ad|Mozilla-LDAP|user"}}`))
+			}, tt.opts...)
+			defer s.Close()
+
+			if _, err := c.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user"); err != nil {
+				t.Fatalf("GetPersonByUserId failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("User-Agent is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package person_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ManagementCycleError is returned when following managers leads back to a
+// person already in the chain.
+type ManagementCycleError struct {
+	// UserIDs lists the persons of the loop, starting and ending with the
+	// person seen twice.
+	UserIDs []string
+}
+
+func (e *ManagementCycleError) Error() string {
+	return fmt.Sprintf("Management chain loops: %s", strings.Join(e.UserIDs, " -> "))
+}
+
+// GetManagementChain returns the manager of p, their manager and so on, at
+// most maxDepth persons, nearest first. Managers are resolved by the primary
+// email published by HRIS. The chain ends without an error at a person
+// without a manager, whose manager is not found, or who manages themselves,
+// as the top of the organization may. A longer loop fails with a
+// *ManagementCycleError; other errors are returned with the chain so far.
+func (c *Client) GetManagementChain(ctx context.Context, p *Person, maxDepth int) ([]*Person, error) {
+	if p == nil {
+		return nil, fmt.Errorf("Person must not be nil")
+	}
+	if maxDepth <= 0 {
+		return nil, fmt.Errorf("Max depth must be positive, got %d", maxDepth)
+	}
+
+	chain := []*Person{}
+	path := []string{p.UserID.Value}
+	current := p
+	for len(chain) < maxDepth {
+		email, ok := current.ManagerEmailValue()
+		if !ok || strings.EqualFold(email, current.PrimaryEmail.Value) {
+			return chain, nil
+		}
+		manager, err := c.getPerson(ctx, PRIMARY_EMAIL, email)
+		if errors.Is(err, ErrNotFound) {
+			return chain, nil
+		}
+		if err != nil {
+			return chain, err
+		}
+		for i, userId := range path {
+			if userId == manager.UserID.Value {
+				loop := append(append([]string(nil), path[i:]...), userId)
+				return chain, &ManagementCycleError{UserIDs: loop}
+			}
+		}
+		chain = append(chain, manager)
+		path = append(path, manager.UserID.Value)
+		current = manager
+	}
+	return chain, nil
+}
+
+// GetDirectReports returns the active staff whose manager, as published by
+// HRIS, has the primary email managerEmail, sorted by primary email.
+func (c *Client) GetDirectReports(ctx context.Context, managerEmail string) ([]*Person, error) {
+	if managerEmail == "" {
+		return nil, fmt.Errorf("Manager email must not be empty")
+	}
+	reports, err := c.findByAttribute(ctx, "access_information."+string(Hris), managerEmail, func(p *Person) bool {
+		return p.IsStaff() && isReportOf(p, managerEmail)
+	})
+	if err != nil {
+		return nil, err
+	}
+	SortByPrimaryEmail(reports)
+	return reports, nil
+}
+
+// GetReportingTree returns everyone below the manager with the primary email
+// managerEmail: their direct reports, the reports of those and so on, sorted
+// by primary email. The tree is built from a single scan of all active staff
+// rather than a query per manager. Each person is visited once, so loops in
+// the HRIS data cannot make it recurse forever.
+func (c *Client) GetReportingTree(ctx context.Context, managerEmail string) ([]*Person, error) {
+	if managerEmail == "" {
+		return nil, fmt.Errorf("Manager email must not be empty")
+	}
+	staff, err := c.GetAllActiveStaff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byManager := map[string][]*Person{}
+	for _, p := range Dedupe(staff) {
+		if email, ok := p.ManagerEmailValue(); ok {
+			email = strings.ToLower(email)
+			byManager[email] = append(byManager[email], p)
+		}
+	}
+
+	visited := map[string]bool{strings.ToLower(managerEmail): true}
+	tree := []*Person{}
+	queue := []string{strings.ToLower(managerEmail)}
+	for len(queue) > 0 {
+		reports := byManager[queue[0]]
+		queue = queue[1:]
+		for _, p := range reports {
+			email := strings.ToLower(p.PrimaryEmail.Value)
+			if visited[email] {
+				continue
+			}
+			visited[email] = true
+			tree = append(tree, p)
+			queue = append(queue, email)
+		}
+	}
+	SortByPrimaryEmail(tree)
+	return tree, nil
+}
+
+// isReportOf reports whether p is managed by managerEmail, other than by
+// being the manager themselves.
+func isReportOf(p *Person, managerEmail string) bool {
+	email, ok := p.ManagerEmailValue()
+	return ok && strings.EqualFold(email, managerEmail) && !strings.EqualFold(p.PrimaryEmail.Value, managerEmail)
+}
@@ -0,0 +1,144 @@
+package person_api
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// OrgNode is a person in an org chart together with their direct reports,
+// sorted by primary email.
+type OrgNode struct {
+	Person  *Person
+	Reports []*OrgNode
+}
+
+// OrgChartOptions tune BuildOrgChart.
+type OrgChartOptions struct {
+	// IncludeInactive keeps persons who are no longer active. They are
+	// left out by default, which makes their reports roots.
+	IncludeInactive bool
+}
+
+// BuildOrgChart builds the org chart of all staff, see BuildOrgChartFrom.
+func (c *Client) BuildOrgChart(ctx context.Context, opts OrgChartOptions) ([]*OrgNode, error) {
+	var (
+		staff []*Person
+		err   error
+	)
+	if opts.IncludeInactive {
+		staff, err = c.GetAllUsers(ctx)
+	} else {
+		staff, err = c.GetAllActiveStaff(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	filtered := staff[:0]
+	for _, p := range staff {
+		if p.IsStaff() {
+			filtered = append(filtered, p)
+		}
+	}
+	return BuildOrgChartFrom(filtered, opts)
+}
+
+// BuildOrgChartFrom arranges persons by the manager emails published by
+// HRIS. Persons without a manager among persons, or who manage themselves,
+// become the roots of the returned forest, sorted by primary email. Persons
+// whose managers form a loop fail the build with a *ManagementCycleError.
+func BuildOrgChartFrom(persons []*Person, opts OrgChartOptions) ([]*OrgNode, error) {
+	nodes := map[string]*OrgNode{}
+	var order []*OrgNode
+	for _, p := range Dedupe(persons) {
+		if !opts.IncludeInactive && !p.Active.Value {
+			continue
+		}
+		email := strings.ToLower(p.PrimaryEmail.Value)
+		if email == "" || nodes[email] != nil {
+			continue
+		}
+		node := &OrgNode{Person: p}
+		nodes[email] = node
+		order = append(order, node)
+	}
+
+	managers := map[*OrgNode]*OrgNode{}
+	var roots []*OrgNode
+	for _, node := range order {
+		email, _ := node.Person.ManagerEmailValue()
+		manager := nodes[strings.ToLower(email)]
+		if manager == nil || manager == node {
+			roots = append(roots, node)
+			continue
+		}
+		managers[node] = manager
+		manager.Reports = append(manager.Reports, node)
+	}
+
+	reached := map[*OrgNode]bool{}
+	for _, root := range roots {
+		root.Walk(func(node *OrgNode, _ int) error {
+			reached[node] = true
+			return nil
+		})
+	}
+	if len(reached) < len(order) {
+		return nil, orgCycle(order, reached, managers)
+	}
+
+	sortOrgNodes(roots)
+	for _, node := range order {
+		sortOrgNodes(node.Reports)
+	}
+	return roots, nil
+}
+
+// orgCycle finds a loop among the persons not reached from the roots, all
+// of which have a manager and therefore lead into one.
+func orgCycle(order []*OrgNode, reached map[*OrgNode]bool, managers map[*OrgNode]*OrgNode) error {
+	for _, node := range order {
+		if reached[node] {
+			continue
+		}
+		seen := map[*OrgNode]int{}
+		var path []string
+		for current := node; ; current = managers[current] {
+			if i, ok := seen[current]; ok {
+				return &ManagementCycleError{UserIDs: append(path[i:], current.Person.UserID.Value)}
+			}
+			seen[current] = len(path)
+			path = append(path, current.Person.UserID.Value)
+		}
+	}
+	return nil
+}
+
+func sortOrgNodes(nodes []*OrgNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return lessMissingLast(strings.ToLower(nodes[i].Person.PrimaryEmail.Value), strings.ToLower(nodes[j].Person.PrimaryEmail.Value))
+	})
+}
+
+// Walk calls fn for n and everyone below it, depth first with managers
+// before their reports. depth is 0 for n. If fn returns ErrStopIteration the
+// walk stops and Walk returns nil; any other error stops it and is returned.
+func (n *OrgNode) Walk(fn func(node *OrgNode, depth int) error) error {
+	err := n.walk(fn, 0)
+	if err == ErrStopIteration {
+		return nil
+	}
+	return err
+}
+
+func (n *OrgNode) walk(fn func(*OrgNode, int) error, depth int) error {
+	if err := fn(n, depth); err != nil {
+		return err
+	}
+	for _, report := range n.Reports {
+		if err := report.walk(fn, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package person_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// pageCursor is the value sent back in the nextPage query parameter of
+// /v2/users, already JSON encoded. The API reports it either as an object
+// such as {"id": "..."}, which is passed back unchanged, or as a bare id,
+// which is wrapped into that object. An empty cursor means there are no
+// more pages; see isLastPage for the values the API uses to say so.
+type pageCursor string
+
+type nextPage struct {
+	Id string `json:"id"`
+}
+
+func (p *pageCursor) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*p = ""
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		if isLastPage(id) {
+			*p = ""
+			return nil
+		}
+		encoded, err := json.Marshal(nextPage{Id: id})
+		if err != nil {
+			return err
+		}
+		*p = pageCursor(encoded)
+	case '{':
+		cursor, err := compactCursor(data)
+		if err != nil {
+			return err
+		}
+		*p = pageCursor(cursor)
+	default:
+		return fmt.Errorf("Unexpected nextPage value %s", data)
+	}
+	return nil
+}
+
+// rawCursor is a nextPage value that is passed back verbatim, as done by the
+// /v2/users/id/all endpoints. Object values are kept as compact JSON.
+type rawCursor string
+
+func (p *rawCursor) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*p = ""
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if isLastPage(s) {
+			s = ""
+		}
+		*p = rawCursor(s)
+	case '{':
+		cursor, err := compactCursor(data)
+		if err != nil {
+			return err
+		}
+		*p = rawCursor(cursor)
+	default:
+		return fmt.Errorf("Unexpected nextPage value %s", data)
+	}
+	return nil
+}
+
+// isLastPage reports whether the nextPage string s ends an enumeration. The
+// API renders a missing cursor from Python as "None".
+func isLastPage(s string) bool {
+	return s == "" || s == "None"
+}
+
+// compactCursor returns the object cursor data as compact JSON, or "" if
+// none of its members has a value, such as {} or {"id": null}.
+func compactCursor(data []byte) (string, error) {
+	var members map[string]interface{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return "", err
+	}
+	if !hasCursorValue(members) {
+		return "", nil
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, data); err != nil {
+		return "", err
+	}
+	return compacted.String(), nil
+}
+
+func hasCursorValue(members map[string]interface{}) bool {
+	for _, v := range members {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			if !isLastPage(v) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// pageGuard enforces the enumeration limits of a client for a single
+// enumeration and detects cursors that would fetch a page again.
+type pageGuard struct {
+	maxPages int
+	maxUsers int
+	progress func(fetchedUsers, pages int)
+	pages    int
+	users    int
+	seen     map[string]bool
+}
+
+// newPageGuard starts an enumeration at cursor, which is empty for the
+// first page.
+func (c *Client) newPageGuard(cursor string) *pageGuard {
+	return &pageGuard{
+		maxPages: c.maxPages,
+		maxUsers: c.maxUsers,
+		progress: c.progress,
+		seen:     map[string]bool{cursor: true},
+	}
+}
+
+// next records a fetched page of n users that ended with cursor. It fails if
+// the page exceeds the user limit, or if cursor would need a page beyond the
+// page limit or one that was already fetched.
+func (g *pageGuard) next(n int, cursor string) error {
+	g.pages++
+	g.users += n
+	if g.progress != nil {
+		g.progress(g.users, g.pages)
+	}
+	if g.users > g.maxUsers {
+		return &LimitError{Limit: "users", Max: g.maxUsers}
+	}
+	if cursor == "" {
+		return nil
+	}
+	if g.pages >= g.maxPages {
+		return &LimitError{Limit: "pages", Max: g.maxPages}
+	}
+	if g.seen[cursor] {
+		return fmt.Errorf("%w after %d pages", ErrRepeatedCursor, g.pages)
+	}
+	g.seen[cursor] = true
+	return nil
+}
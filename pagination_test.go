@@ -0,0 +1,190 @@
+package person_api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// listers enumerate the two kinds of paginated endpoints, whose pages hold
+// their entries under different keys.
+var listers = []struct {
+	name  string
+	key   string
+	fetch func(*person_api.Client) error
+}{
+	{"GetAllUsers", "Items", func(c *person_api.Client) error {
+		_, err := c.GetAllUsers(context.Background())
+		return err
+	}},
+	{"GetAllUserIDs", "users", func(c *person_api.Client) error {
+		_, err := c.GetAllUserIDs(context.Background())
+		return err
+	}},
+}
+
+func TestPageTerminators(t *testing.T) {
+	terminators := map[string]string{
+		"None":         `, "nextPage": "None"`,
+		"null":         `, "nextPage": null`,
+		"missing":      ``,
+		"empty string": `, "nextPage": ""`,
+		"empty object": `, "nextPage": {}`,
+		"null id":      `, "nextPage": {"id": null}`,
+		"None id":      `, "nextPage": {"id": "None"}`,
+	}
+	for _, l := range listers {
+		for name, terminator := range terminators {
+			t.Run(l.name+"/"+name, func(t *testing.T) {
+				var requests int64
+				c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+					if atomic.AddInt64(&requests, 1) > 1 {
+						// Stop a client that did not see the last page.
+						fmt.Fprintf(w, `{%q: [], "nextPage": "None"}`, l.key)
+						return
+					}
+					fmt.Fprintf(w, `{%q: []%s}`, l.key, terminator)
+				})
+				defer s.Close()
+
+				if err := l.fetch(c); err != nil {
+					t.Fatalf("%s failed: %v", l.name, err)
+				}
+				if got := atomic.LoadInt64(&requests); got != 1 {
+					t.Errorf("%s requested %d pages, want 1", l.name, got)
+				}
+			})
+		}
+	}
+}
+
+func TestRepeatedCursor(t *testing.T) {
+	// next maps the cursor of each request to the nextPage of its response.
+	tests := map[string]map[string]string{
+		"same cursor twice": {"": "a", "a": "a"},
+		"cycle":             {"": "a", "a": "b", "b": "a"},
+	}
+	for _, l := range listers {
+		for name, next := range tests {
+			t.Run(l.name+"/"+name, func(t *testing.T) {
+				var requests int64
+				c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+					if atomic.AddInt64(&requests, 1) > int64(len(next)) {
+						fmt.Fprintf(w, `{%q: [], "nextPage": "None"}`, l.key)
+						return
+					}
+					// Cursors go back as {"id": ...} for GetAllUsers and
+					// verbatim for GetAllUserIDs.
+					cursor := r.URL.Query().Get("nextPage")
+					var id struct{ ID string }
+					if json.Unmarshal([]byte(cursor), &id) == nil {
+						cursor = id.ID
+					}
+					fmt.Fprintf(w, `{%q: [], "nextPage": %q}`, l.key, next[cursor])
+				})
+				defer s.Close()
+
+				if err := l.fetch(c); !errors.Is(err, person_api.ErrRepeatedCursor) {
+					t.Errorf("%s = %v, want ErrRepeatedCursor", l.name, err)
+				}
+				if got := atomic.LoadInt64(&requests); got != int64(len(next)) {
+					t.Errorf("%s requested %d pages, want %d", l.name, got, len(next))
+				}
+			})
+		}
+	}
+}
+
+func TestPaginationReusesConnections(t *testing.T) {
+	var conns int64
+	s := &slowServer{pages: 10}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	s.Start()
+	defer s.Close()
+	c := s.newClient(t)
+	defer c.Close()
+
+	users, err := c.GetAllUsers(context.Background())
+	if err != nil || len(users) != s.pages {
+		t.Fatalf("GetAllUsers = %d users, %v, want %d users", len(users), err, s.pages)
+	}
+	// The token request and every page go over the same connection when
+	// each body is read and closed before the next request.
+	if got := atomic.LoadInt64(&conns); got != 1 {
+		t.Errorf("Token request and %d pages opened %d connections, want 1", s.pages, got)
+	}
+}
+
+func TestCursorEscaping(t *testing.T) {
+	tests := []struct {
+		name string
+		// sent is the nextPage value of the first page, want the nextPage
+		// parameter the client must send back for the second.
+		sent string
+		want string
+	}{
+		{"space", `"user 1"`, `{"id":"user 1"}`},
+		{"plus", `"a+b@example.com"`, `{"id":"a+b@example.com"}`},
+		{"unicode", `"Zoë 名前"`, `{"id":"Zoë 名前"}`},
+		{"reserved", `"a&b=c#d%20"`, `{"id":"a\u0026b=c#d%20"}`},
+		{"object", `{"id": "a b+c", "ts": "ünï"}`, `{"id":"a b+c","ts":"ünï"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				cursor, ok := r.URL.Query()["nextPage"]
+				if !ok {
+					fmt.Fprintf(w, `{"Items": [], "nextPage": %s}`, tt.sent)
+					return
+				}
+				got = append(got, cursor...)
+				fmt.Fprint(w, `{"Items": [], "nextPage": "None"}`)
+			})
+			defer s.Close()
+
+			if _, err := c.GetAllUsers(context.Background()); err != nil {
+				t.Fatalf("GetAllUsers failed: %v", err)
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("nextPage sent back = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawCursorEscaping(t *testing.T) {
+	for _, cursor := range []string{"user 1", "a+b@example.com", "Zoë 名前", "a&b=c#d%20"} {
+		var got []string
+		c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+			sent, ok := r.URL.Query()["nextPage"]
+			if !ok {
+				fmt.Fprintf(w, `{"users": [], "nextPage": %q}`, cursor)
+				return
+			}
+			got = append(got, sent...)
+			fmt.Fprint(w, `{"users": [], "nextPage": null}`)
+		})
+
+		if _, err := c.GetAllUserIDs(context.Background()); err != nil {
+			t.Errorf("GetAllUserIDs with cursor %q failed: %v", cursor, err)
+		}
+		if len(got) != 1 || got[0] != cursor {
+			t.Errorf("nextPage sent back = %q, want %q", got, cursor)
+		}
+		s.Close()
+	}
+}
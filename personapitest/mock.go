@@ -0,0 +1,170 @@
+// Package personapitest provides test doubles for consumers of the
+// person_api package.
+package personapitest
+
+import (
+	"context"
+	"sync"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// Call records a single invocation of a MockClient method.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// MockClient is an in-memory person_api.PersonAPI. Lookups of persons that
+// have not been added return person_api.ErrNotFound.
+type MockClient struct {
+	// Err, when set, is returned by every method instead of a result.
+	Err error
+
+	mu         sync.Mutex
+	persons    []*person_api.Person
+	byEmail    map[string]*person_api.Person
+	byUserId   map[string]*person_api.Person
+	byUUID     map[string]*person_api.Person
+	byUsername map[string]*person_api.Person
+	calls      []Call
+}
+
+var _ person_api.PersonAPI = (*MockClient)(nil)
+
+func NewMockClient(persons ...*person_api.Person) *MockClient {
+	m := &MockClient{
+		byEmail:    map[string]*person_api.Person{},
+		byUserId:   map[string]*person_api.Person{},
+		byUUID:     map[string]*person_api.Person{},
+		byUsername: map[string]*person_api.Person{},
+	}
+	for _, p := range persons {
+		m.AddPerson(p)
+	}
+	return m
+}
+
+// AddPerson registers p under its primary email, user_id, uuid and primary
+// username, whichever are set.
+func (m *MockClient) AddPerson(p *person_api.Person) {
+	if p == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.persons = append(m.persons, p)
+	if v := p.PrimaryEmail.Value; v != "" {
+		m.byEmail[v] = p
+	}
+	if v := p.UserID.Value; v != "" {
+		m.byUserId[v] = p
+	}
+	if v := p.UUID.Value; v != "" {
+		m.byUUID[v] = p
+	}
+	if v := p.PrimaryUsername.Value; v != "" {
+		m.byUsername[v] = p
+	}
+}
+
+// Calls returns the invocations recorded so far, in order.
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (m *MockClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *MockClient) record(method string, args ...interface{}) {
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func (m *MockClient) lookup(index map[string]*person_api.Person, method, id string) (*person_api.Person, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.record(method, id)
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	p, ok := index[id]
+	if !ok {
+		return nil, person_api.ErrNotFound
+	}
+	return p, nil
+}
+
+func (m *MockClient) GetPersonByEmail(ctx context.Context, primaryEmail string) (*person_api.Person, error) {
+	return m.lookup(m.byEmail, "GetPersonByEmail", primaryEmail)
+}
+
+func (m *MockClient) GetPersonByUserId(ctx context.Context, userid string) (*person_api.Person, error) {
+	return m.lookup(m.byUserId, "GetPersonByUserId", userid)
+}
+
+func (m *MockClient) GetPersonByUUID(ctx context.Context, uuid string) (*person_api.Person, error) {
+	return m.lookup(m.byUUID, "GetPersonByUUID", uuid)
+}
+
+func (m *MockClient) GetPersonByUsername(ctx context.Context, primaryUsername string) (*person_api.Person, error) {
+	return m.lookup(m.byUsername, "GetPersonByUsername", primaryUsername)
+}
+
+// GetPersonBy dispatches to the lookup of field.
+func (m *MockClient) GetPersonBy(ctx context.Context, field person_api.LookupField, id string) (*person_api.Person, error) {
+	switch field {
+	case person_api.USERID:
+		return m.GetPersonByUserId(ctx, id)
+	case person_api.UUID:
+		return m.GetPersonByUUID(ctx, id)
+	case person_api.PRIMARY_EMAIL:
+		return m.GetPersonByEmail(ctx, id)
+	case person_api.PRIMARY_USERNAME:
+		return m.GetPersonByUsername(ctx, id)
+	}
+	return nil, &person_api.UnknownLookupFieldError{Field: field.String()}
+}
+
+func (m *MockClient) GetAllUsers(ctx context.Context) ([]*person_api.Person, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.record("GetAllUsers")
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	persons := make([]*person_api.Person, len(m.persons))
+	copy(persons, m.persons)
+	return persons, nil
+}
+
+// GetPersonsInGroups returns the added persons that are in any of the given
+// LDAP groups.
+func (m *MockClient) GetPersonsInGroups(ctx context.Context, groups []string) ([]*person_api.Person, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.record("GetPersonsInGroups", groups)
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	persons := []*person_api.Person{}
+	for _, p := range m.persons {
+		if p == nil {
+			continue
+		}
+		for _, g := range groups {
+			if _, ok := p.AccessInformation.LDAP.Values[g]; ok {
+				persons = append(persons, p)
+				break
+			}
+		}
+	}
+	return persons, nil
+}
@@ -0,0 +1,65 @@
+package personapitest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+	"go.mozilla.org/person-api/personapitest"
+)
+
+func TestMockClientFixtures(t *testing.T) {
+	ctx := context.Background()
+	want := newPerson(1)
+	m := personapitest.NewMockClient(newPerson(0))
+	m.AddPerson(want)
+
+	for _, lookup := range []func() (*person_api.Person, error){
+		func() (*person_api.Person, error) { return m.GetPersonByEmail(ctx, want.PrimaryEmail.Value) },
+		func() (*person_api.Person, error) { return m.GetPersonByUserId(ctx, want.UserID.Value) },
+		func() (*person_api.Person, error) { return m.GetPersonByUUID(ctx, want.UUID.Value) },
+		func() (*person_api.Person, error) { return m.GetPersonByUsername(ctx, want.PrimaryUsername.Value) },
+	} {
+		if p, err := lookup(); p != want || err != nil {
+			t.Errorf("Lookup = %v, %v, want the added person", p, err)
+		}
+	}
+	if _, err := m.GetPersonByEmail(ctx, "unknown@mozilla.com"); !errors.Is(err, person_api.ErrNotFound) {
+		t.Errorf("Lookup of an unknown email = %v, want ErrNotFound", err)
+	}
+	if users, err := m.GetAllUsers(ctx); len(users) != 2 || err != nil {
+		t.Errorf("GetAllUsers = %d users, %v, want 2 users", len(users), err)
+	}
+
+	m.Err = errors.New("unavailable")
+	if _, err := m.GetPersonByEmail(ctx, want.PrimaryEmail.Value); err != m.Err {
+		t.Errorf("Lookup with Err set = %v, want %v", err, m.Err)
+	}
+	if _, err := m.GetAllUsers(ctx); err != m.Err {
+		t.Errorf("GetAllUsers with Err set = %v, want %v", err, m.Err)
+	}
+}
+
+func TestMockClientCalls(t *testing.T) {
+	ctx := context.Background()
+	m := personapitest.NewMockClient()
+	m.GetPersonByEmail(ctx, "a@mozilla.com")
+	m.GetPersonBy(ctx, person_api.USERID, "ad|Mozilla-LDAP|a")
+	m.GetPersonsInGroups(ctx, []string{"team"})
+
+	want := []personapitest.Call{
+		{Method: "GetPersonByEmail", Args: []interface{}{"a@mozilla.com"}},
+		{Method: "GetPersonByUserId", Args: []interface{}{"ad|Mozilla-LDAP|a"}},
+		{Method: "GetPersonsInGroups", Args: []interface{}{[]string{"team"}}},
+	}
+	if got := m.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Calls() = %v, want %v", got, want)
+	}
+
+	m.Reset()
+	if got := m.Calls(); len(got) != 0 {
+		t.Errorf("Calls() after Reset = %v, want none", got)
+	}
+}
@@ -0,0 +1,470 @@
+package personapitest
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// Credentials accepted by the token endpoint of Server.
+const (
+	ClientId     = "personapitest-client"
+	ClientSecret = "personapitest-secret"
+)
+
+// Server is a fake Person API and token endpoint backed by a fixed set of
+// persons. It issues bearer tokens, rejects requests without a valid one
+// with a 401, paginates the user listings and serves the lookup routes.
+type Server struct {
+	*httptest.Server
+
+	// PageSize is the number of users per page of the listings.
+	PageSize int
+	// TokenLifetime is the expires_in of issued tokens.
+	TokenLifetime time.Duration
+	// ETags makes the lookup routes send ETags and answer requests with a
+	// matching If-None-Match with 304 Not Modified.
+	ETags bool
+	// FailPage, if positive, makes the listings answer requests for that
+	// page, counting from 1, with a 500 Internal Server Error.
+	FailPage int
+
+	t             testing.TB
+	mu            sync.Mutex
+	persons       []*person_api.Person
+	profiles      []map[string]interface{}
+	tokens        map[string]time.Time
+	tokenRequests int
+}
+
+// NewServer starts a Server serving persons. Close it when done. Persons
+// that cannot be encoded fail t, and so do handler failures, which are
+// answered with a 500.
+func NewServer(t testing.TB, persons []*person_api.Person) *Server {
+	t.Helper()
+	s := &Server{
+		PageSize:      25,
+		TokenLifetime: time.Hour,
+		t:             t,
+		tokens:        map[string]time.Time{},
+	}
+	for _, p := range persons {
+		profile, err := toProfile(p)
+		if err != nil {
+			t.Fatalf("Encoding the profile of %s failed: %v", p.UserID.Value, err)
+		}
+		s.persons = append(s.persons, p)
+		s.profiles = append(s.profiles, profile)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", s.handleToken)
+	mux.HandleFunc("/v2/users", s.authenticated(s.handleUsers))
+	mux.HandleFunc("/v2/users/id/all", s.authenticated(s.handleUserIds))
+	mux.HandleFunc("/v2/users/id/all/by_attribute_contains", s.authenticated(s.handleByAttribute))
+	mux.HandleFunc("/v2/user/", s.authenticated(s.handleUser))
+	s.Server = httptest.NewServer(gzipped(mux))
+	return s
+}
+
+// gzipped compresses the responses to requests accepting gzip, as the
+// Person API does.
+func gzipped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		next.ServeHTTP(gzipResponseWriter{ResponseWriter: w, w: gz}, r)
+	})
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	w io.Writer
+}
+
+func (g gzipResponseWriter) Write(p []byte) (int, error) {
+	return g.w.Write(p)
+}
+
+func (s *Server) AuthURL() string {
+	return s.URL + "/oauth/token"
+}
+
+// NewClient returns a client configured for s. opts are applied last.
+func (s *Server) NewClient(opts ...person_api.Option) (*person_api.Client, error) {
+	opts = append([]person_api.Option{
+		person_api.WithBaseURL(s.URL),
+		person_api.WithAuthURL(s.AuthURL()),
+	}, opts...)
+	return person_api.NewClient(ClientId, ClientSecret, opts...)
+}
+
+// ExpireTokens invalidates every token issued so far.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]time.Time{}
+}
+
+// TokenRequests returns how many tokens have been requested.
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req person_api.AuthReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	s.mu.Lock()
+	s.tokenRequests++
+	s.mu.Unlock()
+	if req.GrantType != "client_credentials" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "unauthorized_client", "error_description": "Grant type not allowed"})
+		return
+	}
+	if req.ClientId != ClientId || req.ClientSecret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "access_denied", "error_description": "Unauthorized"})
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		s.fail(w, "Generating a token failed: %v", err)
+		return
+	}
+	s.mu.Lock()
+	s.tokens[token] = time.Now().Add(s.TokenLifetime)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, person_api.AuthResp{
+		AccessToken: token,
+		Scope:       req.Scope,
+		ExpiresIn:   int(s.TokenLifetime / time.Second),
+		TokenType:   "Bearer",
+	})
+}
+
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		expiresAt, ok := s.tokens[token]
+		s.mu.Unlock()
+		if token == "" || !ok || time.Now().After(expiresAt) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Unauthorized"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleUsers serves /v2/users, where cursors are {"id": "<offset>"}
+// objects and the last page ends with "None".
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	offset := 0
+	if raw := r.URL.Query().Get("nextPage"); raw != "" {
+		var cursor struct {
+			Id string `json:"id"`
+		}
+		var err error
+		if err = json.Unmarshal([]byte(raw), &cursor); err == nil {
+			offset, err = strconv.Atoi(cursor.Id)
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid nextPage"})
+			return
+		}
+	}
+
+	if s.failPage(w, offset) {
+		return
+	}
+	matches := s.filter(r.URL.Query())
+	page, next := s.page(matches, offset)
+	items := []map[string]interface{}{}
+	for _, i := range page {
+		items = append(items, s.profiles[i])
+	}
+	var nextPage interface{} = "None"
+	if next >= 0 {
+		nextPage = map[string]string{"id": strconv.Itoa(next)}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"Items": items, "nextPage": nextPage})
+}
+
+func (s *Server) handleUserIds(w http.ResponseWriter, r *http.Request) {
+	offset, ok := stringOffset(w, r)
+	if !ok {
+		return
+	}
+	if s.failPage(w, offset) {
+		return
+	}
+	page, next := s.page(s.filter(r.URL.Query()), offset)
+	users := []person_api.UserID{}
+	for _, i := range page {
+		users = append(users, person_api.UserID{
+			UserID:       s.persons[i].UserID.Value,
+			PrimaryEmail: s.persons[i].PrimaryEmail.Value,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"users": users, "nextPage": nextOffset(next)})
+}
+
+func (s *Server) handleByAttribute(w http.ResponseWriter, r *http.Request) {
+	offset, ok := stringOffset(w, r)
+	if !ok {
+		return
+	}
+	if s.failPage(w, offset) {
+		return
+	}
+	q := r.URL.Query()
+	full := q.Get("fullProfiles") == "True"
+	page, next := s.page(s.filter(q), offset)
+	users := []map[string]interface{}{}
+	for _, i := range page {
+		u := map[string]interface{}{"id": s.profiles[i]["user_id"]}
+		if full {
+			u["profile"] = s.profiles[i]
+		}
+		users = append(users, u)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"users": users, "nextPage": nextOffset(next)})
+}
+
+// handleUser serves the /v2/user/<field>/<id> lookups, answering unknown
+// identifiers with an empty object like the real API.
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.EscapedPath(), "/v2/user/"), "/", 2)
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := url.PathUnescape(parts[1])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	var value func(p *person_api.Person) string
+	switch parts[0] {
+	case "user_id":
+		value = func(p *person_api.Person) string { return p.UserID.Value }
+	case "uuid":
+		value = func(p *person_api.Person) string { return p.UUID.Value }
+	case "primary_email":
+		value = func(p *person_api.Person) string { return p.PrimaryEmail.Value }
+	case "primary_username":
+		value = func(p *person_api.Person) string { return p.PrimaryUsername.Value }
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	for i, p := range s.persons {
+		if value(p) == id {
+			if s.ETags && s.notModified(w, r, s.profiles[i]) {
+				return
+			}
+			writeJSON(w, http.StatusOK, s.profiles[i])
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// notModified sets the ETag of profile and answers with 304 Not Modified if
+// the request already has it.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request, profile map[string]interface{}) bool {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		s.fail(w, "Encoding a profile failed: %v", err)
+		return true
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// filter returns the indexes of the persons matching the query parameters
+// other than nextPage and fullProfiles. Dotted parameters such as
+// staff_information.staff or access_information.ldap match attribute values
+// and keys of attribute value maps.
+func (s *Server) filter(q url.Values) []int {
+	var matches []int
+	for i, profile := range s.profiles {
+		ok := true
+		for key, values := range q {
+			switch key {
+			case "nextPage", "fullProfiles":
+				continue
+			case "connectionMethod":
+				ok = strings.HasPrefix(s.persons[i].UserID.Value, connectionPrefix(values[0]))
+			default:
+				ok = matchesAttribute(profile, key, values[0])
+			}
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// failPage answers with a 500 if the page starting at offset is FailPage.
+func (s *Server) failPage(w http.ResponseWriter, offset int) bool {
+	if s.FailPage <= 0 || offset/s.pageSize()+1 != s.FailPage {
+		return false
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+	return true
+}
+
+func (s *Server) pageSize() int {
+	if s.PageSize <= 0 {
+		return 25
+	}
+	return s.PageSize
+}
+
+func (s *Server) page(matches []int, offset int) ([]int, int) {
+	size := s.pageSize()
+	if offset >= len(matches) {
+		return nil, -1
+	}
+	end := offset + size
+	if end >= len(matches) {
+		return matches[offset:], -1
+	}
+	return matches[offset:end], end
+}
+
+func connectionPrefix(connection string) string {
+	switch connection {
+	case person_api.ConnectionAD:
+		return "ad|"
+	case person_api.ConnectionGithub:
+		return "github|"
+	case person_api.ConnectionEmail:
+		return "email|"
+	case person_api.ConnectionGoogleOAuth2:
+		return "google-oauth2|"
+	case person_api.ConnectionFirefoxAccounts:
+		return "oauth2|firefoxaccounts|"
+	}
+	return connection + "|"
+}
+
+func matchesAttribute(profile map[string]interface{}, path, want string) bool {
+	var node interface{} = profile
+	for _, key := range strings.Split(path, ".") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		node = m[key]
+	}
+	attr, ok := node.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if values, ok := attr["values"].(map[string]interface{}); ok {
+		for k, v := range values {
+			if k == want || fmt.Sprint(v) == want {
+				return true
+			}
+		}
+		return false
+	}
+	switch v := attr["value"].(type) {
+	case bool:
+		return (v && want == "True") || (!v && want == "False")
+	case string:
+		return v == want
+	}
+	return false
+}
+
+func stringOffset(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("nextPage")
+	if raw == "" {
+		return 0, true
+	}
+	offset, err := strconv.Atoi(raw)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid nextPage"})
+		return 0, false
+	}
+	return offset, true
+}
+
+func nextOffset(next int) interface{} {
+	if next < 0 {
+		return nil
+	}
+	return strconv.Itoa(next)
+}
+
+func toProfile(p *person_api.Person) (map[string]interface{}, error) {
+	data, err := p.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var profile map[string]interface{}
+	err = json.Unmarshal(data, &profile)
+	return profile, err
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// fail reports a failure of a handler to the test, which may not be stopped
+// from a handler goroutine, and answers with a 500.
+func (s *Server) fail(w http.ResponseWriter, format string, args ...interface{}) {
+	s.t.Errorf(format, args...)
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "Internal server error"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,149 @@
+package personapitest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+	"go.mozilla.org/person-api/personapitest"
+)
+
+func newPerson(i int) *person_api.Person {
+	p := &person_api.Person{}
+	p.UserID.Value = fmt.Sprintf("ad|Mozilla-LDAP|user%d", i)
+	p.UUID.Value = fmt.Sprintf("00000000-0000-4000-8000-%012d", i)
+	p.PrimaryEmail.Value = fmt.Sprintf("user%d@mozilla.com", i)
+	p.PrimaryUsername.Value = fmt.Sprintf("user%d", i)
+	p.Active.Value = true
+	return p
+}
+
+func newPersons(n int) []*person_api.Person {
+	persons := make([]*person_api.Person, n)
+	for i := range persons {
+		persons[i] = newPerson(i)
+	}
+	return persons
+}
+
+func TestServerAuth(t *testing.T) {
+	s := personapitest.NewServer(t, nil)
+	defer s.Close()
+
+	c, err := s.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+	if got := s.TokenRequests(); got != 1 {
+		t.Errorf("TokenRequests() = %d, want 1", got)
+	}
+
+	_, err = person_api.NewClient(personapitest.ClientId, "wrong",
+		person_api.WithBaseURL(s.URL), person_api.WithAuthURL(s.AuthURL()))
+	var authErr *person_api.AuthError
+	if !errors.As(err, &authErr) || authErr.Code != "access_denied" {
+		t.Errorf("NewClient with a wrong secret = %v, want an access_denied *AuthError", err)
+	}
+}
+
+func TestServerBearerValidation(t *testing.T) {
+	s := personapitest.NewServer(t, newPersons(1))
+	defer s.Close()
+	ctx := context.Background()
+
+	forged, err := person_api.NewClientWithToken("forged", s.URL)
+	if err != nil {
+		t.Fatalf("NewClientWithToken failed: %v", err)
+	}
+	var unauthorized *person_api.UnauthorizedError
+	if _, err := forged.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|user0"); !errors.As(err, &unauthorized) {
+		t.Errorf("Lookup with a forged token = %v, want an *UnauthorizedError", err)
+	}
+
+	c, err := s.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+	s.ExpireTokens()
+	if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|user0"); err != nil {
+		t.Errorf("Lookup after the token expired = %v, want a refresh and success", err)
+	}
+	if got := s.TokenRequests(); got != 2 {
+		t.Errorf("TokenRequests() = %d, want 2", got)
+	}
+}
+
+func TestServerPagination(t *testing.T) {
+	s := personapitest.NewServer(t, newPersons(7))
+	defer s.Close()
+	s.PageSize = 3
+	c, err := s.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	var pages int
+	users, err := c.GetAllUsers(ctx)
+	if err != nil || len(users) != 7 {
+		t.Errorf("GetAllUsers = %d users, %v, want 7 users", len(users), err)
+	}
+	for i, u := range users {
+		if want := newPerson(i).UserID.Value; u.UserID.Value != want {
+			t.Errorf("users[%d] = %s, want %s", i, u.UserID.Value, want)
+		}
+	}
+
+	counted, err := s.NewClient(person_api.WithProgress(func(_, p int) { pages = p }))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer counted.Close()
+	ids, err := counted.GetAllUserIDs(ctx)
+	if err != nil || len(ids) != 7 {
+		t.Errorf("GetAllUserIDs = %d ids, %v, want 7 ids", len(ids), err)
+	}
+	if pages != 3 {
+		t.Errorf("GetAllUserIDs fetched %d pages, want 3", pages)
+	}
+}
+
+func TestServerLookups(t *testing.T) {
+	s := personapitest.NewServer(t, newPersons(3))
+	defer s.Close()
+	c, err := s.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	want := newPerson(1)
+	lookups := []struct {
+		field person_api.LookupField
+		id    string
+	}{
+		{person_api.USERID, want.UserID.Value},
+		{person_api.UUID, want.UUID.Value},
+		{person_api.PRIMARY_EMAIL, want.PrimaryEmail.Value},
+		{person_api.PRIMARY_USERNAME, want.PrimaryUsername.Value},
+	}
+	for _, l := range lookups {
+		p, err := c.GetPersonBy(ctx, l.field, l.id)
+		if err != nil {
+			t.Errorf("GetPersonBy(%s, %q) failed: %v", l.field, l.id, err)
+			continue
+		}
+		if p.UserID.Value != want.UserID.Value {
+			t.Errorf("GetPersonBy(%s, %q) = %s, want %s", l.field, l.id, p.UserID.Value, want.UserID.Value)
+		}
+		if _, err := c.GetPersonBy(ctx, l.field, "unknown"); !errors.Is(err, person_api.ErrNotFound) {
+			t.Errorf("GetPersonBy(%s, unknown) = %v, want ErrNotFound", l.field, err)
+		}
+	}
+}
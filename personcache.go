@@ -0,0 +1,148 @@
+package person_api
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PersonCacheRecorder may be implemented by a StatsRecorder to observe the
+// hits and misses of the cache installed by WithPersonCache.
+type PersonCacheRecorder interface {
+	ObservePersonCache(hit bool)
+}
+
+// personCache is a read-through LRU cache of person lookups with a TTL.
+// Negative entries record lookups that ended in ErrNotFound.
+type personCache struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type personCacheEntry struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+func newPersonCache(ttl time.Duration, size int) *personCache {
+	return &personCache{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func personCacheKey(method LookupField, id string) string {
+	return strconv.Itoa(int(method)) + "\n" + id
+}
+
+// get returns the cached profile of key, a nil body for a negative entry,
+// and whether key was cached at all.
+func (pc *personCache) get(key string) ([]byte, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	e, ok := pc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*personCacheEntry)
+	if !time.Now().Before(entry.expires) {
+		pc.order.Remove(e)
+		delete(pc.entries, key)
+		return nil, false
+	}
+	pc.order.MoveToFront(e)
+	return entry.body, true
+}
+
+// put caches body, or a negative entry if body is nil and negative entries
+// are enabled.
+func (pc *personCache) put(key string, body []byte) {
+	ttl := pc.ttl
+	if body == nil {
+		ttl = pc.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	entry := &personCacheEntry{key: key, body: body, expires: time.Now().Add(ttl)}
+	if e, ok := pc.entries[key]; ok {
+		e.Value = entry
+		pc.order.MoveToFront(e)
+		return
+	}
+	pc.entries[key] = pc.order.PushFront(entry)
+	for pc.order.Len() > pc.size {
+		oldest := pc.order.Back()
+		pc.order.Remove(oldest)
+		delete(pc.entries, oldest.Value.(*personCacheEntry).key)
+	}
+}
+
+func (pc *personCache) remove(key string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if e, ok := pc.entries[key]; ok {
+		pc.order.Remove(e)
+		delete(pc.entries, key)
+	}
+}
+
+// cachedPerson looks key up in the person cache. It returns ErrNotFound for
+// negative entries and reports whether the cache answered the lookup.
+func (c *Client) cachedPerson(ctx context.Context, key string) (*Person, bool, error) {
+	if c.personCache == nil || !usesCache(ctx) {
+		return nil, false, nil
+	}
+	body, ok := c.personCache.get(key)
+	if recorder, isRecorder := c.stats.(PersonCacheRecorder); isRecorder {
+		recorder.ObservePersonCache(ok)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	if body == nil {
+		return nil, true, ErrNotFound
+	}
+	// Decoding a fresh copy keeps callers from modifying the cached one.
+	p, err := UnmarshalPerson(body)
+	if err != nil {
+		return nil, false, nil
+	}
+	return &p, true, nil
+}
+
+// storePerson caches the outcome of a lookup that fetched body, which is
+// nil unless the lookup succeeded.
+func (c *Client) storePerson(key string, body []byte, err error) {
+	if c.personCache == nil {
+		return
+	}
+	switch {
+	case err == nil && body != nil:
+		c.personCache.put(key, body)
+	case errors.Is(err, ErrNotFound):
+		c.personCache.put(key, nil)
+	}
+}
+
+// InvalidatePerson drops the cached result of looking up id by method, see
+// WithPersonCache. Other lookups of the same person, e.g. by user id instead
+// of primary email, are cached separately.
+func (c *Client) InvalidatePerson(method LookupField, id string) {
+	if c.personCache != nil {
+		c.personCache.remove(personCacheKey(method, id))
+	}
+}
@@ -0,0 +1,44 @@
+package person_api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+func TestNegativePersonCacheOptionOrder(t *testing.T) {
+	for name, opts := range map[string][]person_api.Option{
+		"after":  {person_api.WithPersonCache(time.Hour, 10), person_api.WithNegativePersonCache(time.Minute)},
+		"before": {person_api.WithNegativePersonCache(time.Minute), person_api.WithPersonCache(time.Hour, 10)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var requests int64
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt64(&requests, 1)
+				http.NotFound(w, r)
+			}, opts...)
+			defer s.Close()
+
+			for i := 0; i < 3; i++ {
+				if _, err := c.GetPersonByEmail(context.Background(), "nobody@mozilla.com"); !errors.Is(err, person_api.ErrNotFound) {
+					t.Fatalf("GetPersonByEmail returned %v, want ErrNotFound", err)
+				}
+			}
+			if got := atomic.LoadInt64(&requests); got != 1 {
+				t.Errorf("the server was asked %d times, want the not found result cached after 1", got)
+			}
+		})
+	}
+}
+
+func TestNegativePersonCacheRequiresPersonCache(t *testing.T) {
+	_, err := person_api.NewClientWithToken("token", "http://127.0.0.1", person_api.WithNegativePersonCache(time.Minute))
+	if err == nil {
+		t.Error("NewClientWithToken succeeded without WithPersonCache")
+	}
+}
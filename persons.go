@@ -1,7 +1,9 @@
 package person_api
 
 import (
+	"bytes"
 	"encoding/json"
+	"sort"
 	"strings"
 )
 
@@ -11,8 +13,37 @@ func UnmarshalPerson(data []byte) (Person, error) {
 	return r, err
 }
 
+// UnmarshalPersonStrict is UnmarshalPerson but fails with a
+// *StrictDecodingError if data, or any of the attributes nested in it, has a
+// field Person does not model. Attribute values are free-form and are not
+// checked.
+func UnmarshalPersonStrict(data []byte) (Person, error) {
+	type plain Person
+	var r Person
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode((*plain)(&r)); err != nil {
+		return Person{}, newStrictDecodingError(data, err)
+	}
+	r.raw = append(json.RawMessage(nil), data...)
+	return r, nil
+}
+
 func (r *Person) Marshal() ([]byte, error) {
-	return json.Marshal(r)
+	return MarshalPerson(*r)
+}
+
+// MarshalPerson encodes p in the CIS profile format, the inverse of
+// UnmarshalPerson. Unlike json.Marshal it leaves characters such as <, >
+// and & unescaped, so values round-trip byte for byte.
+func MarshalPerson(p Person) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(p); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
 }
 
 type Person struct {
@@ -44,6 +75,25 @@ type Person struct {
 	UserID            StandardAttributeString         `json:"user_id"`
 	Usernames         StandardAttributeValues         `json:"usernames"`
 	UUID              StandardAttributeString         `json:"uuid"`
+
+	raw json.RawMessage
+}
+
+func (p *Person) UnmarshalJSON(data []byte) error {
+	type plain Person
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	// data may alias a whole page of users, so keep a copy of this
+	// profile only.
+	p.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// Raw returns the JSON p was decoded from, including fields that Person
+// does not model. It is nil for persons that were not decoded.
+func (p *Person) Raw() json.RawMessage {
+	return p.raw
 }
 
 func (p *Person) GetLDAPUsername() string {
@@ -52,20 +102,75 @@ func (p *Person) GetLDAPUsername() string {
 	return userIdSplit[2]
 }
 
+// GetSSHPublicKeys returns the SSH public keys of p, sorted by their name
+// in the profile. Null entries are skipped.
 func (p *Person) GetSSHPublicKeys() []string {
-	var keys []string
-	for _, v := range p.SSHPublicKeys.Values.(map[string]interface{}) {
-		keys = append(keys, v.(string))
-	}
-	return keys
+	keys, _ := p.SSHPublicKeysValues()
+	return sortedValues(keys)
 }
 
 func (p *Person) GetPGPPublicKeys() []string {
-	var keys []string
-	for _, v := range p.PGPPublicKeys.Values.(map[string]interface{}) {
-		keys = append(keys, v.(string))
+	keys, _ := p.PGPPublicKeysValues()
+	return sortedValues(keys)
+}
+
+func sortedValues(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name, v := range values {
+		if v != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	sorted := make([]string, len(names))
+	for i, name := range names {
+		sorted[i] = values[name]
+	}
+	return sorted
+}
+
+// LDAPGroups returns the sorted names of the LDAP groups of p, or an empty
+// slice if there are none.
+func (p *Person) LDAPGroups() []string {
+	return groupNames(groupValues(p, LDAP))
+}
+
+func (p *Person) HRISGroups() []string {
+	return groupNames(groupValues(p, Hris))
+}
+
+func (p *Person) MozilliansGroups() []string {
+	return groupNames(groupValues(p, Mozilliansorg))
+}
+
+// Groups returns the groups of p from all providers, sorted by provider and
+// name.
+func (p *Person) Groups() []GroupRef {
+	refs := []GroupRef{}
+	for _, provider := range GroupProviders {
+		for _, name := range groupNames(groupValues(p, provider)) {
+			refs = append(refs, GroupRef{Provider: provider, Name: name})
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Provider < refs[j].Provider
+	})
+	return refs
+}
+
+// HasGroup reports whether p is in the named group of provider, or of any
+// provider if provider is empty.
+func (p *Person) HasGroup(provider PublisherAuthority, name string) bool {
+	return GroupRef{Provider: provider, Name: name}.matches(p)
+}
+
+func groupNames(values map[string]interface{}) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
 	}
-	return keys
+	sort.Strings(names)
+	return names
 }
 
 type AccessInformationValuesArray struct {
@@ -0,0 +1,78 @@
+package person_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readFixtures returns the contents of the profiles matching pattern in
+// testdata, by file name.
+func readFixtures(t *testing.T, pattern string) map[string][]byte {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", pattern))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no fixtures match %s: %v", pattern, err)
+	}
+	fixtures := map[string][]byte{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures[filepath.Base(path)] = data
+	}
+	return fixtures
+}
+
+func TestMarshalPersonRoundTrip(t *testing.T) {
+	for name, data := range readFixtures(t, "valid/*.json") {
+		t.Run(name, func(t *testing.T) {
+			first, err := UnmarshalPersonStrict(data)
+			if err != nil {
+				t.Fatalf("UnmarshalPersonStrict failed: %v", err)
+			}
+			encoded, err := MarshalPerson(first)
+			if err != nil {
+				t.Fatalf("MarshalPerson failed: %v", err)
+			}
+			second, err := UnmarshalPersonStrict(encoded)
+			if err != nil {
+				t.Fatalf("UnmarshalPersonStrict of the marshaled profile failed: %v", err)
+			}
+
+			// raw holds the bytes each was decoded from, which only differ
+			// in layout.
+			first.raw, second.raw = nil, nil
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("profile changed in the round trip:\nbefore %+v\nafter  %+v", first, second)
+			}
+
+			reencoded, err := MarshalPerson(second)
+			if err != nil {
+				t.Fatalf("MarshalPerson failed: %v", err)
+			}
+			if !bytes.Equal(encoded, reencoded) {
+				t.Errorf("marshaling is not stable:\n%s\n%s", encoded, reencoded)
+			}
+		})
+	}
+}
+
+func TestMarshalPersonDoesNotEscapeHTML(t *testing.T) {
+	var p Person
+	p.Description.Value = `<b>&</b>`
+	encoded, err := MarshalPerson(p)
+	if err != nil {
+		t.Fatalf("MarshalPerson failed: %v", err)
+	}
+	if !bytes.Contains(encoded, []byte(`"value":"<b>&</b>"`)) {
+		t.Errorf("MarshalPerson escaped the description: %s", encoded)
+	}
+	if !json.Valid(encoded) {
+		t.Errorf("MarshalPerson returned invalid JSON: %s", encoded)
+	}
+}
@@ -0,0 +1,33 @@
+package person_api
+
+import (
+	"context"
+	"net/http"
+)
+
+// pingUserId is looked up by Ping. It is not a valid user id, so the API
+// answers with a small empty profile or a 404.
+const pingUserId = "person-api-go|ping"
+
+// Ping checks that the Person API can be reached and accepts the access
+// token of c by looking up a user that does not exist, which obtains a token
+// first if needed. An empty profile or a 404 means the API is healthy. A
+// rejected token is reported as an *UnauthorizedError, missing permissions
+// as a *ForbiddenError, and any other error status, such as a 400, a 429 or
+// a server error, as an *APIError; a failure to reach the API is a
+// transport error. Ping bypasses the response and person caches, and costs
+// a single small request, so it is suitable for readiness checks.
+func (c *Client) Ping(ctx context.Context) (err error) {
+	ctx, span := c.startSpan(ctx, "Ping")
+	defer func() { span.End(err) }()
+
+	resp, err := c.getAuthenticated(ctx, joinPath(c.baseUrl, "/v2/user/user_id", escapePathSegment(pingUserId)))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return c.newAPIError(resp)
+	}
+	drainAndClose(resp.Body)
+	return nil
+}
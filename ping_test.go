@@ -0,0 +1,72 @@
+package person_api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		// check reports whether err is the expected outcome.
+		check func(err error) bool
+	}{
+		{"empty profile", http.StatusOK, `{}`, isNil},
+		{"null profile", http.StatusOK, `{"user_id": {"value": null}, "primary_email": {"value": null}}`, isNil},
+		{"not found", http.StatusNotFound, `{"message": "Not found"}`, isNil},
+		{"unauthorized", http.StatusUnauthorized, ``, func(err error) bool {
+			var target *person_api.UnauthorizedError
+			return errors.As(err, &target)
+		}},
+		{"forbidden", http.StatusForbidden, ``, func(err error) bool {
+			var target *person_api.ForbiddenError
+			return errors.As(err, &target)
+		}},
+		{"bad request", http.StatusBadRequest, `{"message": "Bad request"}`, isAPIError(http.StatusBadRequest)},
+		{"throttled", http.StatusTooManyRequests, `{"message": "Too many requests"}`, isAPIError(http.StatusTooManyRequests)},
+		{"server error", http.StatusInternalServerError, ``, isAPIError(http.StatusInternalServerError)},
+		{"unavailable", http.StatusServiceUnavailable, ``, isAPIError(http.StatusServiceUnavailable)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}, person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1}))
+			defer s.Close()
+
+			if err := c.Ping(context.Background()); !tt.check(err) {
+				t.Errorf("Ping() = %v", err)
+			}
+		})
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {},
+		person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1}))
+	s.Close()
+
+	var apiErr *person_api.APIError
+	if err := c.Ping(context.Background()); err == nil || errors.As(err, &apiErr) {
+		t.Errorf("Ping() = %v, want a transport error", err)
+	}
+}
+
+func isNil(err error) bool {
+	return err == nil
+}
+
+func isAPIError(status int) func(error) bool {
+	return func(err error) bool {
+		var apiErr *person_api.APIError
+		return errors.As(err, &apiErr) && apiErr.StatusCode == status
+	}
+}
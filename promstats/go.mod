@@ -0,0 +1,10 @@
+module go.mozilla.org/person-api/promstats
+
+go 1.13
+
+require (
+	github.com/prometheus/client_golang v1.7.1
+	go.mozilla.org/person-api v0.0.0
+)
+
+replace go.mozilla.org/person-api => ../
@@ -0,0 +1,98 @@
+// Package promstats exports the metrics of a person_api.Client to
+// Prometheus. It is a separate module so that the client does not depend on
+// the Prometheus libraries.
+package promstats
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	person_api "go.mozilla.org/person-api"
+)
+
+// Recorder implements person_api.StatsRecorder,
+// person_api.CircuitStateRecorder and person_api.PersonCacheRecorder.
+type Recorder struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	refreshes       *prometheus.CounterVec
+	refreshDuration prometheus.Histogram
+	circuitState    prometheus.Gauge
+	personCache     *prometheus.CounterVec
+}
+
+var (
+	_ person_api.StatsRecorder        = (*Recorder)(nil)
+	_ person_api.CircuitStateRecorder = (*Recorder)(nil)
+	_ person_api.PersonCacheRecorder  = (*Recorder)(nil)
+)
+
+// New creates a Recorder and registers its metrics with reg, e.g.
+// prometheus.DefaultRegisterer. Pass it to person_api.WithStats.
+func New(reg prometheus.Registerer) (*Recorder, error) {
+	r := &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "person_api_requests_total",
+			Help: "HTTP requests sent to the Person API and its auth endpoint, by status code.",
+		}, []string{"method", "endpoint", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "person_api_request_duration_seconds",
+			Help:    "Duration of HTTP requests sent to the Person API and its auth endpoint.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "endpoint"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "person_api_token_refreshes_total",
+			Help: "Access token requests, by result.",
+		}, []string{"result"}),
+		refreshDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "person_api_token_refresh_duration_seconds",
+			Help:    "Duration of access token requests.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		circuitState: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "person_api_circuit_state",
+			Help: "State of the circuit breaker: 0 closed, 1 open, 2 half-open.",
+		}),
+		personCache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "person_api_person_cache_lookups_total",
+			Help: "Person lookups answered by the person cache (hit) or sent to the API (miss).",
+		}, []string{"result"}),
+	}
+	for _, c := range []prometheus.Collector{r.requests, r.requestDuration, r.refreshes, r.refreshDuration, r.circuitState, r.personCache} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) ObserveRequest(method, endpoint string, status int, duration time.Duration) {
+	code := "error"
+	if status > 0 {
+		code = strconv.Itoa(status)
+	}
+	r.requests.WithLabelValues(method, endpoint, code).Inc()
+	r.requestDuration.WithLabelValues(method, endpoint).Observe(duration.Seconds())
+}
+
+func (r *Recorder) ObserveTokenRefresh(duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	r.refreshes.WithLabelValues(result).Inc()
+	r.refreshDuration.Observe(duration.Seconds())
+}
+
+func (r *Recorder) ObserveCircuitState(from, to person_api.CircuitState) {
+	r.circuitState.Set(float64(to))
+}
+
+func (r *Recorder) ObservePersonCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	r.personCache.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,54 @@
+package person_api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WithProxy sends the auth and API requests through the proxy at proxyUrl
+// instead of the one configured by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables. http, https, socks5 and socks5h proxies are
+// supported. HTTPS requests are tunneled through http and https proxies
+// with CONNECT. net/http resolves host names at the proxy for both socks5
+// and socks5h, so the two behave the same. It requires the HTTP client, see
+// WithHTTPClient, to use an *http.Transport.
+func WithProxy(proxyUrl string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(proxyUrl)
+		if err != nil {
+			return fmt.Errorf("Proxy URL %q is invalid: %v", proxyUrl, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("Proxy URL %q must use http, https, socks5 or socks5h", proxyUrl)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("Proxy URL %q has no host", proxyUrl)
+		}
+		return WithProxyFunc(http.ProxyURL(u))(c)
+	}
+}
+
+// WithProxyFunc makes fn choose the proxy of each auth and API request like
+// http.Transport.Proxy, where a nil URL connects directly. For example, to
+// only proxy the API requests:
+//
+//	person_api.WithProxyFunc(func(req *http.Request) (*url.URL, error) {
+//		if req.URL.Host == apiHost {
+//			return proxyUrl, nil
+//		}
+//		return nil, nil
+//	})
+func WithProxyFunc(fn func(*http.Request) (*url.URL, error)) Option {
+	return func(c *Client) error {
+		if fn == nil {
+			return fmt.Errorf("Proxy func must not be nil")
+		}
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.Proxy = fn
+		})
+		return nil
+	}
+}
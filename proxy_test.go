@@ -0,0 +1,257 @@
+package person_api_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// connectProxy tunnels CONNECT requests to their target and records the
+// method and target of every request it receives.
+type connectProxy struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newConnectProxy(t *testing.T) *connectProxy {
+	p := &connectProxy{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.requests = append(p.requests, r.Method+" "+r.Host)
+		p.mu.Unlock()
+		if r.Method != http.MethodConnect {
+			http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		go func() {
+			io.Copy(target, buf)
+			target.Close()
+		}()
+		go func() {
+			io.Copy(conn, target)
+			conn.Close()
+		}()
+	}))
+	return p
+}
+
+func (p *connectProxy) Requests() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.requests...)
+}
+
+func TestProxyTunnelsHTTPS(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+	proxy := newConnectProxy(t)
+	defer proxy.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	c, err := person_api.NewClientWithToken("token", s.URL,
+		person_api.WithProxy(proxy.URL), person_api.WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if err != nil {
+		t.Fatalf("NewClientWithToken failed: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping through the proxy failed: %v", err)
+	}
+	want := "CONNECT " + s.Listener.Addr().String()
+	if got := proxy.Requests(); len(got) != 1 || got[0] != want {
+		t.Errorf("proxy received %q, want [%q]", got, want)
+	}
+}
+
+// socksProxy is a SOCKS5 proxy without authentication that records the
+// target of every CONNECT request and dials the targets named in hosts at
+// the address they map to.
+type socksProxy struct {
+	net.Listener
+	hosts map[string]string
+
+	mu      sync.Mutex
+	targets []string
+}
+
+func newSocksProxy(t *testing.T, hosts map[string]string) *socksProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	p := &socksProxy{Listener: l, hosts: hosts}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				if err := p.serve(conn); err != nil {
+					t.Errorf("SOCKS5 proxy: %v", err)
+				}
+			}()
+		}
+	}()
+	return p
+}
+
+// serve handles the greeting and the CONNECT request of RFC 1928, then
+// pipes conn to the target.
+func (p *socksProxy) serve(conn net.Conn) error {
+	defer conn.Close()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[0] != 5 {
+		return fmt.Errorf("version %d is not SOCKS5", header[0])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return err
+	}
+	// No authentication required.
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return err
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return err
+	}
+	if request[1] != 1 {
+		return fmt.Errorf("command %d is not CONNECT", request[1])
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return err
+		}
+		host = string(name)
+	default:
+		return fmt.Errorf("address type %d is not supported", request[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return err
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+	p.mu.Lock()
+	p.targets = append(p.targets, target)
+	p.mu.Unlock()
+
+	addr := target
+	if mapped, ok := p.hosts[host]; ok {
+		addr = mapped
+	}
+	upstream, err := net.Dial("tcp", addr)
+	if err != nil {
+		// General failure, bound to 0.0.0.0:0.
+		conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return err
+	}
+	defer upstream.Close()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+	go func() {
+		io.Copy(upstream, conn)
+		upstream.Close()
+	}()
+	io.Copy(conn, upstream)
+	return nil
+}
+
+func (p *socksProxy) Targets() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+func TestProxySOCKS5(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+	_, port, err := net.SplitHostPort(s.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// person-api.invalid cannot be resolved, so lookups only succeed if
+	// the proxy resolves it.
+	apiUrl := "http://person-api.invalid:" + port
+
+	for _, scheme := range []string{"socks5", "socks5h"} {
+		t.Run(scheme, func(t *testing.T) {
+			proxy := newSocksProxy(t, map[string]string{"person-api.invalid": s.Listener.Addr().String()})
+			defer proxy.Close()
+
+			c, err := person_api.NewClientWithToken("token", apiUrl, person_api.WithProxy(scheme+"://"+proxy.Addr().String()))
+			if err != nil {
+				t.Fatalf("NewClientWithToken failed: %v", err)
+			}
+			defer c.Close()
+
+			if err := c.Ping(context.Background()); err != nil {
+				t.Fatalf("Ping through the proxy failed: %v", err)
+			}
+			want := "person-api.invalid:" + port
+			if got := proxy.Targets(); len(got) != 1 || got[0] != want {
+				t.Errorf("proxy connected to %q, want [%q]", got, want)
+			}
+		})
+	}
+}
+
+func TestProxyURLValidation(t *testing.T) {
+	for _, proxyUrl := range []string{"ftp://proxy:21", "socks4://proxy:1080", "http://", "://proxy"} {
+		if _, err := person_api.NewClientWithToken("token", "https://person.api", person_api.WithProxy(proxyUrl)); err == nil {
+			t.Errorf("WithProxy(%q) succeeded, want an error", proxyUrl)
+		}
+	}
+	for _, proxyUrl := range []string{"http://proxy:3128", "https://proxy:3128", "socks5://proxy:1080", "socks5h://proxy:1080"} {
+		if _, err := person_api.NewClientWithToken("token", "https://person.api", person_api.WithProxy(proxyUrl)); err != nil {
+			t.Errorf("WithProxy(%q) failed: %v", proxyUrl, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package person_api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// GetPersonsByCostCenter returns the active staff of the cost center, sorted
+// by primary email.
+func (c *Client) GetPersonsByCostCenter(ctx context.Context, costCenter string) ([]*Person, error) {
+	if costCenter == "" {
+		return nil, fmt.Errorf("Cost center must not be empty")
+	}
+	return c.findStaffBy(ctx, "staff_information.cost_center", costCenter, (*Person).CostCenter)
+}
+
+// GetPersonsByWorkerType returns the active staff of the worker type, such
+// as "Employee" or "Contractor", sorted by primary email.
+func (c *Client) GetPersonsByWorkerType(ctx context.Context, workerType string) ([]*Person, error) {
+	if workerType == "" {
+		return nil, fmt.Errorf("Worker type must not be empty")
+	}
+	return c.findStaffBy(ctx, "staff_information.worker_type", workerType, (*Person).WorkerType)
+}
+
+// findStaffBy returns the active staff for which value returns want.
+// Profiles without staff_information never match.
+func (c *Client) findStaffBy(ctx context.Context, attr, want string, value func(*Person) (string, bool)) ([]*Person, error) {
+	persons, err := c.findByAttribute(ctx, attr, want, func(p *Person) bool {
+		v, ok := value(p)
+		return ok && v == want && p.IsStaff()
+	})
+	if err != nil {
+		return nil, err
+	}
+	SortByPrimaryEmail(persons)
+	return persons, nil
+}
+
+// findByAttribute returns the active persons for which match holds among
+// those whose attribute attr contains value. The attribute filter of the API
+// narrows the candidates; if it is rejected all active users are streamed
+// and matched locally instead.
+func (c *Client) findByAttribute(ctx context.Context, attr, value string, match func(*Person) bool) ([]*Person, error) {
+	attrs := url.Values{}
+	attrs.Set(attr, value)
+	candidates, err := c.getByAttribute(ctx, attrs)
+	if isRejectedFilter(err) {
+		matches := []*Person{}
+		err = c.ForEachUserWithQuery(ctx, UsersQuery{Active: Bool(true)}, func(p *Person) error {
+			if match(p) {
+				matches = append(matches, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return Dedupe(matches), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*Person{}
+	for _, p := range Dedupe(candidates) {
+		if match(p) {
+			matches = append(matches, p)
+		}
+	}
+	return matches, nil
+}
+
+// findUnique returns the single active person found by findByAttribute,
+// ErrNotFound if there is none and an *AmbiguousMatchError if there are
+// several.
+func (c *Client) findUnique(ctx context.Context, attr, value string, match func(*Person) bool) (*Person, error) {
+	matches, err := c.findByAttribute(ctx, attr, value, match)
+	if err != nil {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+		return nil, ErrNotFound
+	case 1:
+		return matches[0], nil
+	}
+	userIds := make([]string, len(matches))
+	for i, p := range matches {
+		userIds[i] = p.UserID.Value
+	}
+	sort.Strings(userIds)
+	return nil, &AmbiguousMatchError{Attribute: attr, Value: value, UserIDs: userIds}
+}
@@ -0,0 +1,66 @@
+package person_api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by all requests of a client. It
+// refills at rate tokens per second up to burst.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a request may be sent and returns how long it waited.
+// It returns ctx.Err() if ctx is done first, and context.DeadlineExceeded
+// right away if the deadline would pass before the wait is over.
+func (l *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// Taking the token before sleeping reserves it, so waiters are served
+	// in order.
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return 0, nil
+	}
+	err := ctx.Err()
+	if deadline, ok := ctx.Deadline(); ok && err == nil && time.Until(deadline) < delay {
+		err = context.DeadlineExceeded
+	}
+	if err == nil {
+		err = sleepContext(ctx, delay)
+	}
+	if err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return 0, err
+	}
+	return delay, nil
+}
@@ -0,0 +1,104 @@
+package person_api
+
+import "reflect"
+
+// DisplayLevel is the audience an attribute may be shown to, as recorded in
+// its metadata.display.
+type DisplayLevel = DinoParkDisplay
+
+// displayRanks orders the display levels from least to most restrictive.
+var displayRanks = map[DisplayLevel]int{
+	Public:        0,
+	Authenticated: 1,
+	Vouched:       2,
+	Ndaed:         3,
+	Staff:         4,
+	Private:       5,
+}
+
+// visibleAt reports whether an attribute with the given display may be shown
+// at level. Attributes with a missing or unknown display are only visible at
+// the Private level.
+func visibleAt(display DisplayLevel, level DisplayLevel) bool {
+	levelRank, ok := displayRanks[level]
+	if !ok {
+		return false
+	}
+	rank, ok := displayRanks[display]
+	if !ok {
+		return levelRank >= displayRanks[Private]
+	}
+	return rank <= levelRank
+}
+
+// Redact returns a deep copy of p in which every attribute whose display is
+// more restrictive than level has been zeroed, including the nested
+// identities, access_information and staff_information attributes. This
+// allows a profile fetched with staff scopes to be handed on as e.g. its
+// Public view.
+func (p *Person) Redact(level DisplayLevel) (*Person, error) {
+	data, err := p.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	redacted, err := UnmarshalPerson(data)
+	if err != nil {
+		return nil, err
+	}
+	redactValue(reflect.ValueOf(&redacted).Elem(), level)
+	// The raw profile holds the unredacted values.
+	redacted.raw = nil
+	return &redacted, nil
+}
+
+func redactValue(v reflect.Value, level DisplayLevel) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if display, ok := attributeDisplay(v.Elem()); ok && !visibleAt(display, level) {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		redactValue(v.Elem(), level)
+	case reflect.Struct:
+		if display, ok := attributeDisplay(v); ok {
+			if !visibleAt(display, level) {
+				v.Set(reflect.Zero(v.Type()))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				redactValue(v.Field(i), level)
+			}
+		}
+	}
+}
+
+// attributeDisplay returns metadata.display of v if v is an attribute, that
+// is a struct with a Metadata field.
+func attributeDisplay(v reflect.Value) (DisplayLevel, bool) {
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+	metadata := v.FieldByName("Metadata")
+	if !metadata.IsValid() || metadata.Kind() != reflect.Struct {
+		return "", false
+	}
+	display := metadata.FieldByName("Display")
+	if !display.IsValid() {
+		return "", false
+	}
+	switch display.Kind() {
+	case reflect.String:
+		return DisplayLevel(display.String()), true
+	case reflect.Interface:
+		if s, ok := display.Interface().(string); ok {
+			return DisplayLevel(s), true
+		}
+		return "", true
+	}
+	return "", false
+}
@@ -0,0 +1,156 @@
+package person_api
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// DefaultResponseCacheSize is the number of profiles kept by a
+// MemoryResponseCache created with a size of zero.
+const DefaultResponseCacheSize = 256
+
+// CachedResponse is a person lookup stored in a ResponseCache together with
+// the ETag the API sent for it.
+type CachedResponse struct {
+	ETag string
+	Body []byte
+}
+
+// ResponseCache stores person lookups, see WithResponseCache. Keys identify
+// the lookup URL and scopes and never contain the identifier in clear.
+type ResponseCache interface {
+	// Get returns nil and no error if key is not cached.
+	Get(key string) (*CachedResponse, error)
+	Put(key string, resp CachedResponse) error
+	Delete(key string) error
+}
+
+type noResponseCacheKey struct{}
+
+// WithoutResponseCache returns a context that makes person lookups using it
+// skip the response cache and the person cache, for callers that need the
+// current profile. The fresh profile still replaces a copy held by the
+// person cache.
+func WithoutResponseCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noResponseCacheKey{}, true)
+}
+
+func usesCache(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noResponseCacheKey{}).(bool)
+	return !disabled
+}
+
+// responseCacheKey returns the cache key of a lookup of personUrl, or "" if
+// ctx or the client does not use the response cache. The scopes are part of
+// the key since they determine which attributes the API returns.
+func (c *Client) responseCacheKey(ctx context.Context, personUrl string) string {
+	if c.responseCache == nil || !usesCache(ctx) {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(c.scope + "\n" + personUrl))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedResponse returns the cached lookup of key, if any. Cache failures
+// only cost a full response, so they are ignored.
+func (c *Client) cachedResponse(key string) *CachedResponse {
+	if key == "" {
+		return nil
+	}
+	cached, err := c.responseCache.Get(key)
+	if err != nil || cached == nil || cached.ETag == "" {
+		return nil
+	}
+	return cached
+}
+
+// storeResponse caches body under key if the API sent an ETag for it, and
+// otherwise drops a previously cached copy that can no longer be
+// revalidated.
+func (c *Client) storeResponse(key string, resp *http.Response, body []byte, cached *CachedResponse) {
+	if key == "" {
+		return
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		if cached != nil {
+			c.responseCache.Delete(key)
+		}
+		return
+	}
+	c.responseCache.Put(key, CachedResponse{ETag: etag, Body: body})
+}
+
+// MemoryResponseCache is a ResponseCache keeping the most recently used
+// profiles in memory.
+type MemoryResponseCache struct {
+	size    int
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+var _ ResponseCache = (*MemoryResponseCache)(nil)
+
+type memoryResponseEntry struct {
+	key  string
+	resp CachedResponse
+}
+
+// NewMemoryResponseCache returns a cache holding up to size profiles, or
+// DefaultResponseCacheSize if size is zero or negative.
+func NewMemoryResponseCache(size int) *MemoryResponseCache {
+	if size <= 0 {
+		size = DefaultResponseCacheSize
+	}
+	return &MemoryResponseCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (m *MemoryResponseCache) Get(key string) (*CachedResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	m.order.MoveToFront(e)
+	resp := e.Value.(*memoryResponseEntry).resp
+	return &resp, nil
+}
+
+// Put stores resp and evicts the least recently used profile if the cache
+// is full.
+func (m *MemoryResponseCache) Put(key string, resp CachedResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		e.Value.(*memoryResponseEntry).resp = resp
+		m.order.MoveToFront(e)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryResponseEntry{key: key, resp: resp})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryResponseEntry).key)
+	}
+	return nil
+}
+
+func (m *MemoryResponseCache) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		m.order.Remove(e)
+		delete(m.entries, key)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package person_api
+
+import "strings"
+
+// Scope is an OAuth scope understood by the Person API.
+type Scope string
+
+const (
+	ScopeClassificationPublic                 Scope = "classification:public"
+	ScopeClassificationMozillaConfidential    Scope = "classification:mozilla_confidential"
+	ScopeClassificationWorkgroup              Scope = "classification:workgroup"
+	ScopeClassificationStaff                  Scope = "classification:workgroup:staff_only"
+	ScopeClassificationIndividualConfidential Scope = "classification:individual_confidential"
+
+	ScopeDisplayPublic        Scope = "display:public"
+	ScopeDisplayAuthenticated Scope = "display:authenticated"
+	ScopeDisplayVouched       Scope = "display:vouched"
+	ScopeDisplayNdaed         Scope = "display:ndaed"
+	ScopeDisplayStaff         Scope = "display:staff"
+	ScopeDisplayAll           Scope = "display:all"
+
+	ScopeSearchAll Scope = "search:all"
+	ScopeWriteAll  Scope = "write:all"
+)
+
+// ScopeSet is an ordered set of scopes.
+type ScopeSet []Scope
+
+var DefaultScopes = NewScopeSet(ScopeClassificationPublic, ScopeDisplayPublic, ScopeSearchAll)
+
+func NewScopeSet(scopes ...Scope) ScopeSet {
+	var s ScopeSet
+	s.Add(scopes...)
+	return s
+}
+
+// Add appends the scopes that are not part of the set yet.
+func (s *ScopeSet) Add(scopes ...Scope) {
+	for _, scope := range scopes {
+		if !s.Contains(scope) {
+			*s = append(*s, scope)
+		}
+	}
+}
+
+func (s ScopeSet) Contains(scope Scope) bool {
+	for _, existing := range s {
+		if existing == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// String joins the scopes with spaces, as expected by AuthReq.Scope.
+func (s ScopeSet) String() string {
+	parts := make([]string, len(s))
+	for i, scope := range s {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseScopes splits a space or comma separated list of scopes.
+func ParseScopes(s string) ScopeSet {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	var set ScopeSet
+	for _, f := range fields {
+		set.Add(Scope(f))
+	}
+	return set
+}
@@ -0,0 +1,133 @@
+package person_api
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SearchPersons returns the persons matching query, ranked for a search box.
+// query is split into words, each of which must occur in the first name,
+// last name, alternative name or primary username, ignoring case and
+// accents, so "ana mar" finds "Ana María". Persons whose words all match at
+// the start of a name sort before those matching within a name, and ties
+// keep the order of persons.
+//
+// SearchPersons works on a snapshot such as the result of GetAllUsers and
+// makes no requests. It takes O(n·w·l) time for n persons, w query words and
+// names of total length l, plus O(m log m) to rank m matches.
+func SearchPersons(persons []*Person, query string) []*Person {
+	words := strings.Fields(foldSearch(query))
+	matches := []*Person{}
+	if len(words) == 0 {
+		return matches
+	}
+
+	midMatches := map[*Person]int{}
+	for _, p := range persons {
+		if p == nil {
+			continue
+		}
+		names := searchNames(p)
+		mid, ok := 0, true
+		for _, word := range words {
+			prefix, found := matchWord(names, word)
+			if !found {
+				ok = false
+				break
+			}
+			if !prefix {
+				mid++
+			}
+		}
+		if ok {
+			matches = append(matches, p)
+			midMatches[p] = mid
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return midMatches[matches[i]] < midMatches[matches[j]]
+	})
+	return matches
+}
+
+func searchNames(p *Person) []string {
+	var names []string
+	for _, name := range []string{p.FirstName.Value, p.LastName.Value, p.AlternativeName.Value, p.PrimaryUsername.Value} {
+		if name != "" {
+			names = append(names, foldSearch(name))
+		}
+	}
+	return names
+}
+
+// matchWord reports whether word occurs in any of names, and whether it
+// does so at the start of a name or of a word within it.
+func matchWord(names []string, word string) (prefix, found bool) {
+	for _, name := range names {
+		for i := 0; i+len(word) <= len(name); i++ {
+			if !strings.HasPrefix(name[i:], word) {
+				continue
+			}
+			found = true
+			if before, _ := utf8.DecodeLastRuneInString(name[:i]); i == 0 || !isSearchLetter(before) {
+				return true, true
+			}
+		}
+	}
+	return false, found
+}
+
+func isSearchLetter(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// foldSearch lowercases s and strips the accents of Latin letters.
+func foldSearch(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if folded, ok := accentFolds[r]; ok {
+			b.WriteString(folded)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var accentFolds = buildAccentFolds(map[string]string{
+	"a":  "àáâãäåāăą",
+	"ae": "æ",
+	"c":  "çćĉċč",
+	"d":  "ďđð",
+	"e":  "èéêëēĕėęě",
+	"g":  "ĝğġģ",
+	"h":  "ĥħ",
+	"i":  "ìíîïĩīĭįı",
+	"j":  "ĵ",
+	"k":  "ķ",
+	"l":  "ĺļľŀł",
+	"n":  "ñńņňŉ",
+	"o":  "òóôõöøōŏő",
+	"oe": "œ",
+	"r":  "ŕŗř",
+	"s":  "śŝşš",
+	"ss": "ß",
+	"t":  "ţťŧ",
+	"th": "þ",
+	"u":  "ùúûüũūŭůűų",
+	"w":  "ŵ",
+	"y":  "ýÿŷ",
+	"z":  "źżž",
+})
+
+func buildAccentFolds(letters map[string]string) map[rune]string {
+	folds := map[rune]string{}
+	for base, accented := range letters {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+	return folds
+}
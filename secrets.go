@@ -0,0 +1,72 @@
+package person_api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// Redactor rewrites text captured from requests and responses before it is
+// kept on an *APIError or written by WithDebugTransport, see WithRedactor.
+type Redactor func(text []byte) []byte
+
+// DefaultRedactor replaces the values of client_secret, access_token,
+// refresh_token and id_token fields in JSON and form encoded text by
+// REDACTED. It is applied to every *APIError.
+var DefaultRedactor Redactor = redactSecrets
+
+// WithRedactor adds r to the redaction of the bodies and URLs kept on an
+// *APIError and written by WithDebugTransport, for instance to remove
+// personal data. It runs after DefaultRedactor and before the client
+// secret and access token of the client are replaced wherever they occur.
+func WithRedactor(r Redactor) Option {
+	return func(c *Client) error {
+		if r == nil {
+			return errors.New("Redactor must not be nil")
+		}
+		c.redactors = append(c.redactors, r)
+		return nil
+	}
+}
+
+// redact applies DefaultRedactor, the redactors of c and finally replaces
+// the credentials c knows of, so that they never end up in an error or dump
+// even if a server echoes them in an unexpected shape.
+func (c *Client) redact(text []byte) []byte {
+	text = redactSecrets(text)
+	for _, r := range c.redactors {
+		text = r(text)
+	}
+	c.rwLock.RLock()
+	secrets := []string{c.clientSecret, c.lastSecret, c.accessToken}
+	c.rwLock.RUnlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			text = bytes.Replace(text, []byte(secret), []byte(redacted), -1)
+		}
+	}
+	return text
+}
+
+func (c *Client) redactString(s string) string {
+	return string(c.redact([]byte(s)))
+}
+
+// newAPIError is the package level newAPIError with the redaction of c
+// applied.
+func (c *Client) newAPIError(resp *http.Response) *APIError {
+	return newRedactedAPIError(resp, c.redact)
+}
+
+// redactError removes the credentials of c from the response body kept by
+// an oauth2 token source error, which ends up in its message. Other errors
+// are returned unchanged.
+func (c *Client) redactError(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		retrieveErr.Body = c.redact(retrieveErr.Body)
+	}
+	return err
+}
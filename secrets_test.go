@@ -0,0 +1,158 @@
+package person_api_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+const (
+	testSecret = "client-secret-9f8e7d6c5b4a"
+	testToken  = "access-token-1a2b3c4d5e6f"
+)
+
+// assertNoSecrets fails if text holds the client secret or access token, or
+// the start of either as left by truncation.
+func assertNoSecrets(t *testing.T, what, text string) {
+	t.Helper()
+	for _, secret := range []string{testSecret, testToken} {
+		if strings.Contains(text, secret[:12]) {
+			t.Errorf("%s leaks %q: %s", what, secret, text)
+		}
+	}
+}
+
+// leakingServer answers token requests with auth, or by issuing testToken
+// if auth is nil, and API requests with api. Both are given the request body
+// and Authorization header to echo.
+func leakingServer(auth, api func(w http.ResponseWriter, body []byte, authorization string)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/oauth/token" {
+			if auth == nil {
+				fmt.Fprintf(w, `{"access_token": %q, "expires_in": 3600, "token_type": "Bearer"}`, testToken)
+				return
+			}
+			auth(w, body, r.Header.Get("Authorization"))
+			return
+		}
+		api(w, body, r.Header.Get("Authorization"))
+	}))
+}
+
+func gzipped(w http.ResponseWriter, status int, body string) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(body))
+	zw.Close()
+	w.Header().Set("Content-Encoding", "gzip")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+func TestErrorsDoNotLeakSecrets(t *testing.T) {
+	// A body over maxErrorBodySize with the token cut by the truncation.
+	long := strings.Repeat("x", 4096-16) + testToken
+
+	tests := []struct {
+		name string
+		auth func(w http.ResponseWriter, body []byte, authorization string)
+		api  func(w http.ResponseWriter, body []byte, authorization string)
+	}{
+		{"auth error echoing the request", func(w http.ResponseWriter, body []byte, _ string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error": "access_denied", "error_description": %q}`, "Unauthorized: "+string(body))
+		}, nil},
+		{"auth error in plain text", func(w http.ResponseWriter, body []byte, _ string) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "rejected secret %s", testSecret)
+		}, nil},
+		{"auth error over 4 KiB", func(w http.ResponseWriter, body []byte, _ string) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, strings.Repeat("x", 4096-16)+testSecret)
+		}, nil},
+		{"gzipped auth error", func(w http.ResponseWriter, body []byte, _ string) {
+			gzipped(w, http.StatusBadRequest, string(body))
+		}, nil},
+		{"API error echoing the token", nil, func(w http.ResponseWriter, _ []byte, authorization string) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"message": "bad request", "authorization": %q}`, authorization)
+		}},
+		{"API error over 4 KiB", nil, func(w http.ResponseWriter, _ []byte, _ string) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, long)
+		}},
+		{"gzipped API error over 4 KiB", nil, func(w http.ResponseWriter, _ []byte, _ string) {
+			gzipped(w, http.StatusBadRequest, long)
+		}},
+		{"API error after retries", nil, func(w http.ResponseWriter, _ []byte, authorization string) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, `{"access_token": %q, "echo": %q}`, testToken, authorization)
+		}},
+	}
+	for _, tt := range tests {
+		for _, debug := range []bool{false, true} {
+			name := tt.name
+			if debug {
+				name += " with debug transport"
+			}
+			t.Run(name, func(t *testing.T) {
+				api := tt.api
+				if api == nil {
+					api = func(w http.ResponseWriter, _ []byte, _ string) { fmt.Fprint(w, `{}`) }
+				}
+				s := leakingServer(tt.auth, api)
+				defer s.Close()
+				opts := []person_api.Option{
+					person_api.WithBaseURL(s.URL),
+					person_api.WithAuthURL(s.URL + "/oauth/token"),
+					person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 2}),
+				}
+				var dump bytes.Buffer
+				if debug {
+					opts = append(opts, person_api.WithDebugTransport(&dump))
+				}
+				defer func() { assertNoSecrets(t, "debug dump", dump.String()) }()
+
+				c, err := person_api.NewClient("id", testSecret, opts...)
+				if err != nil {
+					assertNoSecrets(t, "NewClient error", err.Error())
+					return
+				}
+				defer c.Close()
+
+				errs := map[string]error{}
+				_, errs["GetAccessToken"] = c.GetAccessToken(context.Background(), s.URL+"/oauth/token")
+				_, errs["GetPersonByUserId"] = c.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user")
+				_, errs["GetAllUsers"] = c.GetAllUsers(context.Background())
+				errs["Do"] = c.Do(context.Background(), "GET", "/v2/users/id/all", nil, nil)
+				errs["Ping"] = c.Ping(context.Background())
+				failed := false
+				for name, err := range errs {
+					if err == nil {
+						continue
+					}
+					failed = true
+					assertNoSecrets(t, name+" error", err.Error())
+					assertNoSecrets(t, name+" error", fmt.Sprintf("%+v", err))
+					var apiErr *person_api.APIError
+					if errors.As(err, &apiErr) {
+						assertNoSecrets(t, name+" APIError body", apiErr.Body)
+					}
+				}
+				if !failed {
+					t.Errorf("no request failed")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package person_api
+
+// The set operations below identify persons by user_id. Persons without a
+// user_id are never considered equal to another person, so they are kept by
+// Dedupe, Union and Difference and dropped by Intersect. Nil persons are
+// dropped. All preserve the order of their first argument.
+
+// Dedupe drops repeated user_ids, keeping the first occurrence.
+func Dedupe(persons []*Person) []*Person {
+	seen := map[string]bool{}
+	deduped := []*Person{}
+	for _, p := range persons {
+		if p == nil {
+			continue
+		}
+		if id := p.UserID.Value; id != "" {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+		deduped = append(deduped, p)
+	}
+	return deduped
+}
+
+// Union returns the persons of a followed by those of b not in a, without
+// duplicates.
+func Union(a, b []*Person) []*Person {
+	merged := make([]*Person, 0, len(a)+len(b))
+	merged = append(merged, a...)
+	return Dedupe(append(merged, b...))
+}
+
+// Intersect returns the persons of a that are also in b.
+func Intersect(a, b []*Person) []*Person {
+	ids := userIdSet(b)
+	intersection := []*Person{}
+	for _, p := range Dedupe(a) {
+		if id := p.UserID.Value; id != "" && ids[id] {
+			intersection = append(intersection, p)
+		}
+	}
+	return intersection
+}
+
+// Difference returns the persons of a that are not in b.
+func Difference(a, b []*Person) []*Person {
+	ids := userIdSet(b)
+	difference := []*Person{}
+	for _, p := range Dedupe(a) {
+		if id := p.UserID.Value; id == "" || !ids[id] {
+			difference = append(difference, p)
+		}
+	}
+	return difference
+}
+
+func userIdSet(persons []*Person) map[string]bool {
+	ids := map[string]bool{}
+	for _, p := range persons {
+		if p != nil && p.UserID.Value != "" {
+			ids[p.UserID.Value] = true
+		}
+	}
+	return ids
+}
@@ -0,0 +1,185 @@
+package person_api_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// randomPersons returns up to 20 persons drawn from a few user ids so that
+// repeats are common. Some persons have no user_id and some are nil.
+func randomPersons(r *rand.Rand) []*person_api.Person {
+	persons := make([]*person_api.Person, r.Intn(20))
+	for i := range persons {
+		switch n := r.Intn(10); n {
+		case 0:
+		case 1, 2:
+			persons[i] = &person_api.Person{}
+		default:
+			persons[i] = &person_api.Person{}
+			persons[i].UserID.Value = fmt.Sprintf("ad|Mozilla-LDAP|user%d", n)
+		}
+	}
+	return persons
+}
+
+// checkSets runs property against random pairs of person slices.
+func checkSets(t *testing.T, property func(a, b []*person_api.Person) bool) {
+	t.Helper()
+	f := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		return property(randomPersons(r), randomPersons(r))
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+}
+
+// isSubsequence reports whether sub is made of persons of persons, in the
+// same order.
+func isSubsequence(sub, persons []*person_api.Person) bool {
+	i := 0
+	for _, p := range persons {
+		if i < len(sub) && sub[i] == p {
+			i++
+		}
+	}
+	return i == len(sub)
+}
+
+// same reports whether a and b hold the same persons, compared by identity
+// rather than by value as reflect.DeepEqual would.
+func same(a, b []*person_api.Person) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutID returns the persons of persons that have no user_id.
+func withoutID(persons []*person_api.Person) []*person_api.Person {
+	var anonymous []*person_api.Person
+	for _, p := range persons {
+		if p != nil && p.UserID.Value == "" {
+			anonymous = append(anonymous, p)
+		}
+	}
+	return anonymous
+}
+
+// ids returns the set of user ids of persons.
+func ids(persons []*person_api.Person) map[string]bool {
+	set := map[string]bool{}
+	for _, p := range persons {
+		if p != nil && p.UserID.Value != "" {
+			set[p.UserID.Value] = true
+		}
+	}
+	return set
+}
+
+func TestDedupe(t *testing.T) {
+	checkSets(t, func(a, _ []*person_api.Person) bool {
+		deduped := person_api.Dedupe(a)
+		seen := map[string]bool{}
+		for _, p := range deduped {
+			if p == nil || (p.UserID.Value != "" && seen[p.UserID.Value]) {
+				return false
+			}
+			seen[p.UserID.Value] = true
+		}
+		return isSubsequence(deduped, a) &&
+			reflect.DeepEqual(ids(deduped), ids(a)) &&
+			same(withoutID(deduped), withoutID(a)) &&
+			same(person_api.Dedupe(deduped), deduped)
+	})
+}
+
+func TestDedupeKeepsFirst(t *testing.T) {
+	persons := newTestPersons(3)
+	again := newTestPerson(1)
+	got := person_api.Dedupe([]*person_api.Person{persons[0], persons[1], nil, again, persons[2], persons[0]})
+	want := []*person_api.Person{persons[0], persons[1], persons[2]}
+	if !same(got, want) {
+		t.Errorf("got %v, want %v", order(got), order(want))
+	}
+}
+
+func TestUnion(t *testing.T) {
+	checkSets(t, func(a, b []*person_api.Person) bool {
+		union := person_api.Union(a, b)
+		deduped := person_api.Dedupe(a)
+		all := append(append([]*person_api.Person{}, a...), b...)
+		return same(union[:len(deduped)], deduped) &&
+			same(union, person_api.Dedupe(all)) &&
+			len(withoutID(union)) == len(withoutID(a))+len(withoutID(b))
+	})
+}
+
+func TestIntersect(t *testing.T) {
+	checkSets(t, func(a, b []*person_api.Person) bool {
+		intersection := person_api.Intersect(a, b)
+		inB := ids(b)
+		for _, p := range intersection {
+			if p.UserID.Value == "" || !inB[p.UserID.Value] {
+				return false
+			}
+		}
+		for id := range ids(a) {
+			if inB[id] && !ids(intersection)[id] {
+				return false
+			}
+		}
+		return isSubsequence(intersection, person_api.Dedupe(a)) &&
+			len(person_api.Intersect(a, a)) == len(person_api.Dedupe(a))-len(withoutID(a))
+	})
+}
+
+func TestDifference(t *testing.T) {
+	checkSets(t, func(a, b []*person_api.Person) bool {
+		difference := person_api.Difference(a, b)
+		intersection := person_api.Intersect(a, b)
+		deduped := person_api.Dedupe(a)
+		// Every person of a ends up in exactly one of the two.
+		if len(difference)+len(intersection) != len(deduped) {
+			return false
+		}
+		inB := ids(b)
+		for _, p := range difference {
+			if p.UserID.Value != "" && inB[p.UserID.Value] {
+				return false
+			}
+		}
+		return isSubsequence(difference, deduped) &&
+			same(withoutID(difference), withoutID(a)) &&
+			same(withoutID(person_api.Difference(a, a)), withoutID(a))
+	})
+}
+
+func TestSetsNeverMergeEmptyUserIDs(t *testing.T) {
+	x, y := &person_api.Person{}, &person_api.Person{}
+	x.PrimaryEmail.Value = "same@mozilla.com"
+	y.PrimaryEmail.Value = "same@mozilla.com"
+	pair := []*person_api.Person{x, y}
+	for name, got := range map[string][]*person_api.Person{
+		"Dedupe":     person_api.Dedupe([]*person_api.Person{x, y, x}),
+		"Union":      person_api.Union(pair, []*person_api.Person{y}),
+		"Difference": person_api.Difference(pair, pair),
+	} {
+		if len(got) < 2 || got[0] != x || got[1] != y {
+			t.Errorf("%s merged persons without a user_id: got %d persons", name, len(got))
+		}
+	}
+	if got := person_api.Intersect(pair, pair); len(got) != 0 {
+		t.Errorf("Intersect matched persons without a user_id: got %d persons", len(got))
+	}
+}
@@ -0,0 +1,243 @@
+package person_api
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PublisherKeys holds the public keys of each CIS publisher. An attribute
+// verifies if any key of the publisher named in its signature block does.
+type PublisherKeys map[PublisherAuthority][]crypto.PublicKey
+
+// SignatureFailure describes one attribute that did not verify.
+type SignatureFailure struct {
+	// Attribute is the dotted path of the attribute, e.g.
+	// "access_information.ldap".
+	Attribute string
+	Publisher PublisherAuthority
+	Err       error
+}
+
+// SignatureError is returned by VerifySignatures and lists every attribute
+// that failed verification, ordered by attribute path.
+type SignatureError struct {
+	Failures []SignatureFailure
+}
+
+func (e *SignatureError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = fmt.Sprintf("%s (publisher %q): %v", f.Attribute, f.Publisher, f.Err)
+	}
+	return fmt.Sprintf("%d attribute signature(s) failed verification: %s", len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// VerifySignatures checks the publisher signature of each attribute of p
+// against keys. The signature is a JWS whose payload must equal the
+// attribute without its signature block. Attributes without a value, which
+// CIS leaves unsigned, are skipped; a set attribute without a signature
+// fails. Verification is CPU heavy, so it only happens when asked for, see
+// also WithSignatureVerification.
+//
+// The raw profile is verified when p was decoded from JSON, so fields this
+// package does not model are covered as well.
+func (p *Person) VerifySignatures(keys PublisherKeys) error {
+	data := p.raw
+	if data == nil {
+		var err error
+		if data, err = p.Marshal(); err != nil {
+			return err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var profile map[string]interface{}
+	if err := dec.Decode(&profile); err != nil {
+		return fmt.Errorf("Decoding the profile failed: %w", err)
+	}
+
+	var failures []SignatureFailure
+	walkAttributes(profile, "", func(path string, attr map[string]interface{}) {
+		if f := verifyAttribute(attr, keys); f != nil {
+			f.Attribute = path
+			failures = append(failures, *f)
+		}
+	})
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Attribute < failures[j].Attribute })
+	return &SignatureError{Failures: failures}
+}
+
+// walkAttributes calls fn for every attribute below node, that is every
+// object with both a metadata and a signature member.
+func walkAttributes(node map[string]interface{}, prefix string, fn func(string, map[string]interface{})) {
+	for name, child := range node {
+		obj, ok := child.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		_, hasMetadata := obj["metadata"]
+		_, hasSignature := obj["signature"]
+		if hasMetadata && hasSignature {
+			fn(path, obj)
+			continue
+		}
+		walkAttributes(obj, path, fn)
+	}
+}
+
+func verifyAttribute(attr map[string]interface{}, keys PublisherKeys) *SignatureFailure {
+	var sig Signature
+	if data, err := json.Marshal(attr["signature"]); err == nil {
+		json.Unmarshal(data, &sig)
+	}
+	publisher := sig.Publisher.Name
+	if sig.Publisher.Value == "" {
+		if attributeIsEmpty(attr) {
+			return nil
+		}
+		return &SignatureFailure{Publisher: publisher, Err: fmt.Errorf("attribute is set but unsigned")}
+	}
+	candidates := keys[publisher]
+	if len(candidates) == 0 {
+		return &SignatureFailure{Publisher: publisher, Err: fmt.Errorf("no key for publisher")}
+	}
+
+	unsigned := make(map[string]interface{}, len(attr))
+	for k, v := range attr {
+		if k != "signature" {
+			unsigned[k] = v
+		}
+	}
+	var lastErr error
+	for _, key := range candidates {
+		payload, err := verifyJWS(sig.Publisher.Value, func(jwsHeader) (crypto.PublicKey, error) {
+			return key, nil
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(payload))
+		dec.UseNumber()
+		var signed map[string]interface{}
+		if err := dec.Decode(&signed); err != nil {
+			return &SignatureFailure{Publisher: publisher, Err: fmt.Errorf("malformed signed payload: %w", err)}
+		}
+		if !reflect.DeepEqual(signed, unsigned) {
+			return &SignatureFailure{Publisher: publisher, Err: fmt.Errorf("signed payload does not match the attribute")}
+		}
+		return nil
+	}
+	return &SignatureFailure{Publisher: publisher, Err: lastErr}
+}
+
+// attributeIsEmpty reports whether attr has no value, or only a zero one.
+func attributeIsEmpty(attr map[string]interface{}) bool {
+	for _, name := range []string{"value", "values"} {
+		switch v := attr[name].(type) {
+		case nil:
+		case string:
+			if v != "" {
+				return false
+			}
+		case bool:
+			if v {
+				return false
+			}
+		case map[string]interface{}:
+			if len(v) > 0 {
+				return false
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// LoadPublisherKeys fetches the publisher keys from the mozilla-iam
+// well-known document at url, which lists a PEM encoded public key per
+// publisher under publishers_rsa_public_keys. A nil httpClient uses one
+// with DefaultTimeout.
+func LoadPublisherKeys(ctx context.Context, httpClient *http.Client, url string) (PublisherKeys, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	data, _, err := getDocument(ctx, httpClient, url)
+	if err != nil {
+		return nil, err
+	}
+	var doc wellKnownDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Malformed well-known document: %w", err)
+	}
+	return parsePublisherKeys(doc.PublisherKeys)
+}
+
+// parsePublisherKeys accepts a PEM string or a list of them per publisher.
+func parsePublisherKeys(raw map[PublisherAuthority]json.RawMessage) (PublisherKeys, error) {
+	keys := make(PublisherKeys, len(raw))
+	for publisher, value := range raw {
+		var pems []string
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			pems = []string{single}
+		} else if err := json.Unmarshal(value, &pems); err != nil {
+			return nil, fmt.Errorf("Malformed keys of publisher %q", publisher)
+		}
+		for _, p := range pems {
+			key, err := ParsePublicKeyPEM([]byte(p))
+			if err != nil {
+				return nil, fmt.Errorf("Malformed key of publisher %q: %w", publisher, err)
+			}
+			keys[publisher] = append(keys[publisher], key)
+		}
+	}
+	return keys, nil
+}
+
+// ParsePublicKeyPEM decodes a PEM encoded PKIX or PKCS #1 public key.
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			return key, nil
+		}
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
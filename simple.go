@@ -0,0 +1,54 @@
+package person_api
+
+// SimplePerson is a flattened view of a profile, with plain values instead of
+// attributes and without metadata or signatures.
+type SimplePerson struct {
+	UserID          string          `json:"user_id"`
+	UUID            string          `json:"uuid"`
+	PrimaryEmail    string          `json:"primary_email"`
+	PrimaryUsername string          `json:"primary_username"`
+	FirstName       string          `json:"first_name"`
+	LastName        string          `json:"last_name"`
+	Active          bool            `json:"active"`
+	LDAPGroups      []string        `json:"ldap_groups"`
+	StaffInfo       SimpleStaffInfo `json:"staff_information"`
+}
+
+type SimpleStaffInfo struct {
+	CostCenter   string `json:"cost_center"`
+	Team         string `json:"team"`
+	ManagerEmail string `json:"manager_email"`
+}
+
+func (p *Person) Simplify() SimplePerson {
+	if p == nil {
+		return SimplePerson{LDAPGroups: []string{}}
+	}
+	managerEmail, _ := p.ManagerEmailValue()
+	return SimplePerson{
+		UserID:          p.UserID.Value,
+		UUID:            p.UUID.Value,
+		PrimaryEmail:    p.PrimaryEmail.Value,
+		PrimaryUsername: p.PrimaryUsername.Value,
+		FirstName:       p.FirstName.Value,
+		LastName:        p.LastName.Value,
+		Active:          p.Active.Value,
+		LDAPGroups:      p.LDAPGroups(),
+		StaffInfo: SimpleStaffInfo{
+			CostCenter:   p.StaffInformation.CostCenter.Value,
+			Team:         p.StaffInformation.Team.Value,
+			ManagerEmail: managerEmail,
+		},
+	}
+}
+
+// SimplifyAll simplifies persons, skipping nil entries.
+func SimplifyAll(persons []*Person) []SimplePerson {
+	simple := make([]SimplePerson, 0, len(persons))
+	for _, p := range persons {
+		if p != nil {
+			simple = append(simple, p.Simplify())
+		}
+	}
+	return simple
+}
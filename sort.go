@@ -0,0 +1,59 @@
+package person_api
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortBy sorts persons in place by less, keeping the order of equal persons.
+// Nil persons sort last and are never passed to less.
+func SortBy(persons []*Person, less func(a, b *Person) bool) {
+	sort.SliceStable(persons, func(i, j int) bool {
+		a, b := persons[i], persons[j]
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return less(a, b)
+	})
+}
+
+// SortByPrimaryEmail sorts persons by primary email, ignoring case. Persons
+// without one sort last.
+func SortByPrimaryEmail(persons []*Person) {
+	SortBy(persons, func(a, b *Person) bool {
+		return lessMissingLast(strings.ToLower(a.PrimaryEmail.Value), strings.ToLower(b.PrimaryEmail.Value))
+	})
+}
+
+// SortByLastName sorts persons by last name and then first name, ignoring
+// case and accents. Persons without a last name sort last.
+func SortByLastName(persons []*Person) {
+	SortBy(persons, func(a, b *Person) bool {
+		aLast, bLast := foldSearch(a.LastName.Value), foldSearch(b.LastName.Value)
+		if aLast != bLast {
+			return lessMissingLast(aLast, bLast)
+		}
+		return lessMissingLast(foldSearch(a.FirstName.Value), foldSearch(b.FirstName.Value))
+	})
+}
+
+// SortByLastModified sorts persons by last_modified, most recent first.
+// Persons without a valid last_modified sort last.
+func SortByLastModified(persons []*Person) {
+	SortBy(persons, func(a, b *Person) bool {
+		aTime, aErr := a.LastModifiedAt()
+		bTime, bErr := b.LastModifiedAt()
+		if aErr != nil || bErr != nil {
+			return aErr == nil && bErr != nil
+		}
+		return aTime.After(bTime)
+	})
+}
+
+// lessMissingLast orders strings with the empty string last.
+func lessMissingLast(a, b string) bool {
+	if a == "" || b == "" {
+		return a != "" && b == ""
+	}
+	return a < b
+}
@@ -0,0 +1,119 @@
+package person_api_test
+
+import (
+	"reflect"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// sortFixture returns n test persons with set applied to each one, in order.
+func sortFixture(n int, set func(i int, p *person_api.Person)) []*person_api.Person {
+	persons := newTestPersons(n)
+	for i, p := range persons {
+		set(i, p)
+	}
+	return persons
+}
+
+// order returns the user ids of persons, with "nil" for nil persons.
+func order(persons []*person_api.Person) []string {
+	ids := make([]string, len(persons))
+	for i, p := range persons {
+		if p == nil {
+			ids[i] = "nil"
+			continue
+		}
+		ids[i] = p.UserID.Value
+	}
+	return ids
+}
+
+func TestSortBy(t *testing.T) {
+	// Sort on the parity of the index only, so every key is shared and the
+	// result shows whether equal persons kept their order.
+	persons := newTestPersons(6)
+	in := []*person_api.Person{nil, persons[0], persons[1], nil, persons[2], persons[3], persons[4], persons[5]}
+	parity := map[*person_api.Person]int{}
+	for i, p := range persons {
+		parity[p] = i % 2
+	}
+	person_api.SortBy(in, func(a, b *person_api.Person) bool {
+		if a == nil || b == nil {
+			t.Fatal("less called with a nil person")
+		}
+		return parity[a] < parity[b]
+	})
+	want := order([]*person_api.Person{persons[0], persons[2], persons[4], persons[1], persons[3], persons[5], nil, nil})
+	if got := order(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSortByEmpty(t *testing.T) {
+	person_api.SortBy(nil, func(a, b *person_api.Person) bool { return true })
+	person_api.SortByPrimaryEmail([]*person_api.Person{})
+	person_api.SortByLastName([]*person_api.Person{nil})
+	person_api.SortByLastModified([]*person_api.Person{nil, nil})
+}
+
+func TestSortByPrimaryEmail(t *testing.T) {
+	emails := []string{"b@mozilla.com", "", "A@mozilla.com", "a@mozilla.com", "", "c@mozilla.com"}
+	persons := sortFixture(len(emails), func(i int, p *person_api.Person) {
+		p.PrimaryEmail.Value = emails[i]
+	})
+	in := append([]*person_api.Person{nil}, persons...)
+	person_api.SortByPrimaryEmail(in)
+	// Emails equal but for case, and missing emails, keep their order.
+	want := order([]*person_api.Person{persons[2], persons[3], persons[0], persons[5], persons[1], persons[4], nil})
+	if got := order(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSortByLastName(t *testing.T) {
+	names := [][2]string{
+		{"Zoë", "Müller"},
+		{"Ana", ""},
+		{"Bob", "muller"},
+		{"", "Adams"},
+		{"Ann", "Müller"},
+		{"", ""},
+		{"Bob", "Muller"},
+	}
+	persons := sortFixture(len(names), func(i int, p *person_api.Person) {
+		p.FirstName.Value, p.LastName.Value = names[i][0], names[i][1]
+	})
+	in := append([]*person_api.Person{nil}, persons...)
+	person_api.SortByLastName(in)
+	want := order([]*person_api.Person{
+		persons[3],                         // Adams
+		persons[4], persons[2], persons[6], // Müller by first name, the two Bobs in input order
+		persons[0],             // Zoë Müller
+		persons[1], persons[5], // no last name, by first name with the missing one last
+		nil,
+	})
+	if got := order(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSortByLastModified(t *testing.T) {
+	times := []string{
+		"2020-01-01T00:00:00.000Z",
+		"",
+		"2021-06-01T00:00:00.000Z",
+		"not a time",
+		"2020-01-01T00:00:00.000Z",
+		"2019-12-31T23:59:59.000Z",
+	}
+	persons := sortFixture(len(times), func(i int, p *person_api.Person) {
+		p.LastModified.Value = times[i]
+	})
+	in := append([]*person_api.Person{nil}, persons...)
+	person_api.SortByLastModified(in)
+	want := order([]*person_api.Person{persons[2], persons[0], persons[4], persons[5], persons[1], persons[3], nil})
+	if got := order(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package person_api
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ValidSSHPublicKeys returns the SSH public keys of p that parse as an
+// authorized_keys line, keyed by their name in the profile. Garbage values
+// and unsupported key types are left out.
+func (p *Person) ValidSSHPublicKeys() map[string]string {
+	keys, _ := p.SSHPublicKeysValues()
+	valid := map[string]string{}
+	for name, key := range keys {
+		if _, ok := parseSSHPublicKey(key); ok {
+			valid[name] = key
+		}
+	}
+	return valid
+}
+
+// SSHPublicKeyFingerprints returns the SHA256 fingerprints of the valid SSH
+// public keys of p, in the "SHA256:..." form printed by ssh-keygen -l, keyed
+// by their name in the profile.
+func (p *Person) SSHPublicKeyFingerprints() map[string]string {
+	keys, _ := p.SSHPublicKeysValues()
+	fingerprints := map[string]string{}
+	for name, key := range keys {
+		if pub, ok := parseSSHPublicKey(key); ok {
+			fingerprints[name] = ssh.FingerprintSHA256(pub)
+		}
+	}
+	return fingerprints
+}
+
+// parseSSHPublicKey parses line as a single "<type> <base64> [comment]"
+// authorized_keys line. ssh.ParseAuthorizedKey skips lines it cannot parse
+// and takes the key type from the blob, so values holding more than one line
+// or naming a type other than the one encoded are rejected here.
+func parseSSHPublicKey(line string) (ssh.PublicKey, bool) {
+	line = strings.TrimSpace(line)
+	if strings.ContainsAny(line, "\r\n") {
+		return nil, false
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil || strings.Fields(line)[0] != pub.Type() {
+		return nil, false
+	}
+	return pub, true
+}
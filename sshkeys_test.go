@@ -0,0 +1,57 @@
+package person_api_test
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sshKey and its fingerprint as printed by ssh-keygen -l.
+const (
+	sshKey            = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICEZr0/6u5CkYpx0bri/0l9ZV5TpmmXofkjcDAQDRETg user@mozilla.com"
+	sshKeyFingerprint = "SHA256:Z+glFYsCeMPTu3sAa1XIXF3b/F4cvN+jkjgptGZJXt0"
+)
+
+func TestValidSSHPublicKeys(t *testing.T) {
+	p := decodePerson(t, `{"ssh_public_keys": {"values": {
+		"laptop": "`+sshKey+`",
+		"padded": "  `+sshKey+`\n",
+		"no comment": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICEZr0/6u5CkYpx0bri/0l9ZV5TpmmXofkjcDAQDRETg",
+		"garbage": "not a key",
+		"empty": "",
+		"null": null,
+		"bad base64": "ssh-ed25519 !!!!",
+		"wrong type": "ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAICEZr0/6u5CkYpx0bri/0l9ZV5TpmmXofkjcDAQDRETg",
+		"truncated": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICEZr0/6u5CkYpx0bri/0l9Z",
+		"second line": "garbage\n`+sshKey+`"
+	}}}`)
+
+	wantKeys := map[string]string{
+		"laptop":     sshKey,
+		"padded":     "  " + sshKey + "\n",
+		"no comment": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICEZr0/6u5CkYpx0bri/0l9ZV5TpmmXofkjcDAQDRETg",
+	}
+	if got := p.ValidSSHPublicKeys(); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("ValidSSHPublicKeys() = %v, want %v", got, wantKeys)
+	}
+
+	wantFingerprints := map[string]string{
+		"laptop":     sshKeyFingerprint,
+		"padded":     sshKeyFingerprint,
+		"no comment": sshKeyFingerprint,
+	}
+	if got := p.SSHPublicKeyFingerprints(); !reflect.DeepEqual(got, wantFingerprints) {
+		t.Errorf("SSHPublicKeyFingerprints() = %v, want %v", got, wantFingerprints)
+	}
+}
+
+func TestValidSSHPublicKeysAbsent(t *testing.T) {
+	for _, profile := range []string{`null`, `{}`, `{"ssh_public_keys": null}`} {
+		p := decodePerson(t, profile)
+		if got := p.ValidSSHPublicKeys(); got == nil || len(got) != 0 {
+			t.Errorf("%s: ValidSSHPublicKeys() = %v, want an empty map", profile, got)
+		}
+		if got := p.SSHPublicKeyFingerprints(); got == nil || len(got) != 0 {
+			t.Errorf("%s: SSHPublicKeyFingerprints() = %v, want an empty map", profile, got)
+		}
+	}
+}
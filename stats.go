@@ -0,0 +1,43 @@
+package person_api
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StatsRecorder receives metrics about the requests of a client, see
+// WithStats. Implementations must be safe for concurrent use and should not
+// block. The promstats package adapts it to Prometheus.
+type StatsRecorder interface {
+	// ObserveRequest is called for every HTTP request, including each
+	// retry. endpoint is a normalized path such as
+	// "/v2/user/primary_email/:id" that never contains identifiers, or
+	// "token" for the auth endpoint. status is 0 if no response arrived.
+	ObserveRequest(method, endpoint string, status int, duration time.Duration)
+	// ObserveTokenRefresh is called for every token request.
+	ObserveTokenRefresh(duration time.Duration, err error)
+}
+
+// CircuitStateRecorder may be implemented by a StatsRecorder to observe the
+// transitions of the circuit breaker installed by WithCircuitBreaker.
+type CircuitStateRecorder interface {
+	ObserveCircuitState(from, to CircuitState)
+}
+
+// endpointLabel normalizes the URL of a request for use as a metrics label.
+func (c *Client) endpointLabel(u *url.URL) string {
+	if auth, err := url.Parse(c.authUrl); err == nil && u.Host == auth.Host && u.Path == auth.Path {
+		return "token"
+	}
+	path := u.Path
+	if base, err := url.Parse(c.baseUrl); err == nil {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(base.Path, "/"))
+	}
+	// Single person lookups carry the identifier as the last segment.
+	if strings.HasPrefix(path, "/v2/user/") {
+		segments := strings.SplitN(strings.TrimPrefix(path, "/v2/user/"), "/", 2)
+		return "/v2/user/" + segments[0] + "/:id"
+	}
+	return path
+}
@@ -0,0 +1,82 @@
+package person_api_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// newHungClient returns a client for a server that never answers, and a
+// function releasing the hung handlers and closing the server.
+func newHungClient(t *testing.T, opts ...person_api.Option) (*person_api.Client, func()) {
+	t.Helper()
+	release := make(chan struct{})
+	opts = append([]person_api.Option{person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1})}, opts...)
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}, opts...)
+	return c, func() {
+		close(release)
+		s.Close()
+	}
+}
+
+func TestTimeoutOnHungServer(t *testing.T) {
+	const window = 200 * time.Millisecond
+
+	tests := []struct {
+		name string
+		opts []person_api.Option
+		call func(*person_api.Client) error
+	}{
+		{
+			name: "request timeout on lookup",
+			opts: []person_api.Option{person_api.WithTimeout(window)},
+			call: func(c *person_api.Client) error {
+				_, err := c.GetPersonByEmail(context.Background(), "user@mozilla.com")
+				return err
+			},
+		},
+		{
+			name: "request timeout on enumeration",
+			opts: []person_api.Option{person_api.WithTimeout(window)},
+			call: func(c *person_api.Client) error {
+				_, err := c.GetAllUsers(context.Background())
+				return err
+			},
+		},
+		{
+			name: "enumeration timeout",
+			opts: []person_api.Option{
+				person_api.WithTimeout(time.Minute),
+				person_api.WithEnumerationTimeout(window),
+			},
+			call: func(c *person_api.Client) error {
+				_, err := c.GetAllUsers(context.Background())
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, closeServer := newHungClient(t, tt.opts...)
+			defer closeServer()
+
+			start := time.Now()
+			err := tt.call(c)
+			elapsed := time.Since(start)
+			if err == nil {
+				t.Fatal("call to a hung server succeeded")
+			}
+			if elapsed > 10*window {
+				t.Errorf("call failed after %s, want about %s", elapsed, window)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package person_api
+
+import (
+	"fmt"
+	"time"
+)
+
+// timestampLayouts are the formats seen in profiles. Fractional seconds are
+// optional in all of them; timestamps without a zone are in UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// ParseTimestamp parses a profile timestamp such as
+// "2019-03-28T23:37:53.231Z".
+func ParseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("Timestamp is empty")
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Cannot parse timestamp %q, expected RFC 3339", s)
+}
+
+// CreatedAt parses the created attribute of p.
+func (p *Person) CreatedAt() (time.Time, error) {
+	t, err := ParseTimestamp(p.Created.Value)
+	if err != nil {
+		return t, fmt.Errorf("Invalid created attribute: %w", err)
+	}
+	return t, nil
+}
+
+// LastModifiedAt parses the last_modified attribute of p.
+func (p *Person) LastModifiedAt() (time.Time, error) {
+	t, err := ParseTimestamp(p.LastModified.Value)
+	if err != nil {
+		return t, fmt.Errorf("Invalid last_modified attribute: %w", err)
+	}
+	return t, nil
+}
+
+// Timestamp returns when the attribute was last modified, or created if
+// last_modified is not set.
+func (m Metadata) Timestamp() (time.Time, error) {
+	if m.LastModified != "" {
+		return ParseTimestamp(m.LastModified)
+	}
+	return ParseTimestamp(m.Created)
+}
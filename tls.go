@@ -0,0 +1,95 @@
+package person_api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// withTLSConfig queues fn to change the TLS configuration of the transport,
+// which is created if the transport has none.
+func withTLSConfig(fn func(*tls.Config)) Option {
+	return func(c *Client) error {
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			fn(t.TLSClientConfig)
+		})
+		return nil
+	}
+}
+
+// WithTLSConfig makes the auth and API requests use a copy of config. The
+// other TLS options modify that copy when given after WithTLSConfig. It
+// requires the HTTP client, see WithHTTPClient, to use an *http.Transport.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) error {
+		if config == nil {
+			return fmt.Errorf("TLS config must not be nil")
+		}
+		config := config.Clone()
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.TLSClientConfig = config.Clone()
+		})
+		return nil
+	}
+}
+
+// WithCACertFile makes the client trust the PEM encoded CA certificates in
+// path in addition to the system roots, or to the roots of a config given
+// to WithTLSConfig before, for deployments behind a gateway with a private
+// CA. The pool of that config is copied rather than added to; before Go
+// 1.19, which cannot copy pools, the system roots are used in its place.
+func WithCACertFile(path string) Option {
+	return func(c *Client) error {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Reading CA certificates failed: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("%s contains no PEM encoded certificates", path)
+		}
+		return withTLSConfig(func(config *tls.Config) {
+			if config.RootCAs == nil {
+				config.RootCAs = pool
+				return
+			}
+			config.RootCAs = cloneCertPool(config.RootCAs)
+			config.RootCAs.AppendCertsFromPEM(data)
+		})(c)
+	}
+}
+
+// WithClientCert makes the client present the certificate in certFile,
+// with the private key in keyFile, to servers that require mutual TLS. Both
+// files are PEM encoded.
+func WithClientCert(certFile, keyFile string) Option {
+	return func(c *Client) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("Loading client certificate failed: %w", err)
+		}
+		return withTLSConfig(func(config *tls.Config) {
+			config.Certificates = append(config.Certificates, cert)
+		})(c)
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of server certificates.
+//
+// INSECURE: this lets anyone on the network impersonate the Person API and
+// the auth endpoint and steal the client secret and access token. It exists
+// for mock servers on localhost and must never be used against a real
+// deployment.
+func WithInsecureSkipVerify() Option {
+	return withTLSConfig(func(config *tls.Config) {
+		config.InsecureSkipVerify = true
+	})
+}
@@ -0,0 +1,98 @@
+package person_api_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// writeCACertFile writes the certificate of s to a PEM file in dir.
+func writeCACertFile(t *testing.T, dir string, s *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(dir, "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newCACert returns a self-signed CA certificate of its own.
+func newCACert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "person-api-go test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestCACertFileDoesNotModifyConfigPool(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "person-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeCACertFile(t, dir, s)
+
+	// The pool of the caller holds an unrelated CA, so its subjects only
+	// change if the client adds the certificate of s to it.
+	pool := x509.NewCertPool()
+	pool.AddCert(newCACert(t))
+	subjects := len(pool.Subjects())
+
+	config := &tls.Config{RootCAs: pool}
+	for i := 0; i < 2; i++ {
+		c, err := person_api.NewClientWithToken("token", s.URL,
+			person_api.WithTLSConfig(config), person_api.WithCACertFile(path))
+		if err != nil {
+			t.Fatalf("NewClientWithToken failed: %v", err)
+		}
+		if err := c.Ping(context.Background()); err != nil {
+			t.Errorf("Ping through the private CA failed: %v", err)
+		}
+		c.Close()
+	}
+	if got := len(pool.Subjects()); got != subjects {
+		t.Errorf("RootCAs of the config has %d subjects after WithCACertFile, want %d", got, subjects)
+	}
+	if config.RootCAs != pool {
+		t.Errorf("WithTLSConfig replaced the RootCAs of the config")
+	}
+}
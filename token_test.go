@@ -0,0 +1,217 @@
+package person_api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+// slowServer issues tokens and serves pages of /v2/users and lookups, each
+// taking delay.
+type slowServer struct {
+	*httptest.Server
+	delay         time.Duration
+	pages         int
+	tokenRequests int64
+	// minToken, if set, makes the API answer 401 to tokens issued before
+	// the minToken-th token request.
+	minToken int64
+}
+
+func newSlowServer(delay time.Duration, pages int) *slowServer {
+	s := &slowServer{delay: delay, pages: pages}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *slowServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(s.delay)
+	if r.URL.Path == "/oauth/token" {
+		n := atomic.AddInt64(&s.tokenRequests, 1)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600, "token_type": "Bearer"}`, n)
+		return
+	}
+
+	var n int64
+	fmt.Sscanf(r.Header.Get("Authorization"), "Bearer token-%d", &n)
+	if n < atomic.LoadInt64(&s.minToken) {
+		http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.Path {
+	case "/v2/users":
+		page := 0
+		if cursor := r.URL.Query().Get("nextPage"); cursor != "" {
+			fmt.Sscanf(cursor, `{"id":"%d"}`, &page)
+		}
+		next := `"None"`
+		if page+1 < s.pages {
+			next = `{"id": "` + strconv.Itoa(page+1) + `"}`
+		}
+		fmt.Fprintf(w, `{"Items": [{"user_id": {"value": "ad|Mozilla-LDAP|user%d"}}], "nextPage": %s}`, page, next)
+	default:
+		fmt.Fprint(w, `{"user_id": {"value": "ad|Mozilla-LDAP|user"}}`)
+	}
+}
+
+func (s *slowServer) TokenRequests() int64 {
+	return atomic.LoadInt64(&s.tokenRequests)
+}
+
+func (s *slowServer) newClient(t *testing.T, opts ...person_api.Option) *person_api.Client {
+	t.Helper()
+	opts = append([]person_api.Option{
+		person_api.WithBaseURL(s.URL),
+		person_api.WithAuthURL(s.URL + "/oauth/token"),
+	}, opts...)
+	c, err := person_api.NewClient("id", "secret", opts...)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return c
+}
+
+// TestRefreshDuringEnumeration refreshes the token and looks persons up
+// while a slow enumeration is running, which must neither block on the
+// enumeration nor race. Run it with -race.
+func TestRefreshDuringEnumeration(t *testing.T) {
+	s := newSlowServer(20*time.Millisecond, 20)
+	defer s.Close()
+	c := s.newClient(t)
+	defer c.Close()
+	ctx := context.Background()
+
+	enumerated := make(chan error, 1)
+	go func() {
+		users, err := c.GetAllUsers(ctx)
+		if err == nil && len(users) != s.pages {
+			err = fmt.Errorf("got %d users, want %d", len(users), s.pages)
+		}
+		enumerated <- err
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if err := c.RefreshAccessToken(ctx); err != nil {
+					t.Errorf("RefreshAccessToken failed: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|user"); err != nil {
+					t.Errorf("GetPersonByUserId failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	// The enumeration alone takes 20 pages of 20ms; refreshes and lookups
+	// finishing well before it shows they were not queued behind it.
+	select {
+	case <-done:
+	case err := <-enumerated:
+		t.Fatalf("Enumeration finished before the refreshes and lookups, err %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Refreshes and lookups blocked during the enumeration")
+	}
+	select {
+	case err := <-enumerated:
+		if err != nil {
+			t.Errorf("GetAllUsers failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetAllUsers did not finish")
+	}
+}
+
+// TestConcurrentUnauthorizedRefreshOnce revokes the token of a client busy
+// with many lookups, which must all succeed after a single refresh.
+func TestConcurrentUnauthorizedRefreshOnce(t *testing.T) {
+	s := newSlowServer(time.Millisecond, 1)
+	defer s.Close()
+	c := s.newClient(t)
+	defer c.Close()
+	ctx := context.Background()
+
+	if got := s.TokenRequests(); got != 1 {
+		t.Fatalf("NewClient made %d token requests, want 1", got)
+	}
+	atomic.StoreInt64(&s.minToken, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetPersonByUserId(ctx, "ad|Mozilla-LDAP|user"); err != nil {
+				t.Errorf("GetPersonByUserId failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.TokenRequests() - 1; got != 1 {
+		t.Errorf("the auth endpoint was hit %d times after the token was revoked, want 1", got)
+	}
+}
+
+// TestFailedRefreshBackoff checks that a token within the expiry margin
+// whose refresh failed is used without refreshing it on every request,
+// until it expires.
+func TestFailedRefreshBackoff(t *testing.T) {
+	var tokenRequests int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/token" {
+			fmt.Fprint(w, `{"user_id": {"value": "ad|Mozilla-LDAP|user"}}`)
+			return
+		}
+		if atomic.AddInt64(&tokenRequests, 1) > 1 {
+			http.Error(w, `{"error": "server_error"}`, http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "token", "expires_in": 1, "token_type": "Bearer"}`)
+	}))
+	defer s.Close()
+	c, err := person_api.NewClient("id", "secret",
+		person_api.WithBaseURL(s.URL),
+		person_api.WithAuthURL(s.URL+"/oauth/token"),
+		person_api.WithExpiryMargin(time.Hour),
+		person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user"); err != nil {
+			t.Fatalf("lookup %d within the margin failed: %v", i, err)
+		}
+	}
+	// The first token, and a single failed refresh for all lookups.
+	if got := atomic.LoadInt64(&tokenRequests); got != 2 {
+		t.Errorf("10 lookups made %d token requests, want 2", got)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := c.GetPersonByUserId(context.Background(), "ad|Mozilla-LDAP|user"); err == nil {
+		t.Errorf("lookup with an expired token and a failing refresh succeeded")
+	}
+	if got := atomic.LoadInt64(&tokenRequests); got != 3 {
+		t.Errorf("lookup after expiry made %d token requests in total, want 3", got)
+	}
+}
@@ -0,0 +1,153 @@
+package person_api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CachedToken is an access token stored in a TokenCache.
+type CachedToken struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Scope       string    `json:"scope,omitempty"`
+	TokenType   string    `json:"token_type,omitempty"`
+}
+
+// TokenCache persists access tokens across clients, see WithTokenCache.
+// Keys identify the client configuration a token was issued for and never
+// contain the client secret.
+type TokenCache interface {
+	// Get returns nil and no error if key is not cached.
+	Get(key string) (*CachedToken, error)
+	Put(key string, token CachedToken) error
+}
+
+// tokenCacheKey derives the cache key from everything that determines which
+// token the auth server issues.
+func (c *Client) tokenCacheKey() string {
+	sum := sha256.Sum256([]byte(c.clientId + "\n" + c.authUrl + "\n" + c.audience + "\n" + c.scope))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCachedToken adopts a cached token that is valid beyond the expiry
+// margin. Cache failures only cost a token request, so they are ignored.
+func (c *Client) loadCachedToken() bool {
+	if c.tokenCache == nil {
+		return false
+	}
+	token, err := c.tokenCache.Get(c.tokenCacheKey())
+	if err != nil || token == nil || token.AccessToken == "" {
+		return false
+	}
+	if !time.Now().Add(c.expiryMargin).Before(token.ExpiresAt) {
+		return false
+	}
+	c.rwLock.Lock()
+	c.accessToken, c.tokenExpiresAt = token.AccessToken, token.ExpiresAt
+	c.tokenInfo = TokenInfo{Scope: token.Scope, TokenType: token.TokenType}
+	c.rwLock.Unlock()
+	return true
+}
+
+// storeCachedToken saves a newly issued token. Tokens without a known
+// lifetime are not cached since they could never be considered expired.
+func (c *Client) storeCachedToken(token string, expiresAt time.Time, info TokenInfo) {
+	if c.tokenCache == nil || expiresAt.IsZero() {
+		return
+	}
+	c.tokenCache.Put(c.tokenCacheKey(), CachedToken{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+		Scope:       info.Scope,
+		TokenType:   info.TokenType,
+	})
+}
+
+// FileTokenCache is a TokenCache keeping all tokens in a single JSON file
+// readable by its owner only.
+type FileTokenCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ TokenCache = (*FileTokenCache)(nil)
+
+func NewFileTokenCache(path string) *FileTokenCache {
+	return &FileTokenCache{path: path}
+}
+
+func (f *FileTokenCache) Get(key string) (*CachedToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tokens, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	token, ok := tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// Put stores token and drops expired entries. The file is replaced
+// atomically so concurrent processes never read a partial file.
+func (f *FileTokenCache) Put(key string, token CachedToken) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tokens, err := f.read()
+	if err != nil {
+		tokens = map[string]CachedToken{}
+	}
+	now := time.Now()
+	for k, t := range tokens {
+		if !t.ExpiresAt.After(now) {
+			delete(tokens, k)
+		}
+	}
+	tokens[key] = token
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// TempFile creates the file with mode 0600.
+	tmp, err := ioutil.TempFile(dir, filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *FileTokenCache) read() (map[string]CachedToken, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return map[string]CachedToken{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tokens := map[string]CachedToken{}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
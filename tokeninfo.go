@@ -0,0 +1,65 @@
+package person_api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenInfo describes the current access token as reported by the auth
+// server.
+type TokenInfo struct {
+	// Scope is the space separated list of granted scopes. It is empty if
+	// the auth server did not report it, which per RFC 6749 means the
+	// requested scopes were granted.
+	Scope     string
+	TokenType string
+	// ExpiresAt is the zero time if the lifetime is unknown.
+	ExpiresAt time.Time
+}
+
+// TokenInfo returns what the auth server reported about the current token.
+// It is empty for tokens supplied via NewClientWithToken or SetAccessToken.
+func (c *Client) TokenInfo() TokenInfo {
+	c.rwLock.RLock()
+	defer c.rwLock.RUnlock()
+	info := c.tokenInfo
+	info.ExpiresAt = c.tokenExpiresAt
+	return info
+}
+
+// GrantedScopes returns the scopes of the current token. If the auth server
+// did not list them, the requested scopes are returned.
+func (c *Client) GrantedScopes() []string {
+	scope := c.TokenInfo().Scope
+	if scope == "" {
+		scope = c.scope
+	}
+	return strings.Fields(scope)
+}
+
+// MissingScopes returns the requested scopes that were not granted, for
+// example a classification scope the client is not entitled to. Profiles
+// fetched without them are silently redacted by the API.
+func (c *Client) MissingScopes() []string {
+	granted := map[string]bool{}
+	for _, s := range c.GrantedScopes() {
+		granted[s] = true
+	}
+	var missing []string
+	for _, s := range strings.Fields(c.scope) {
+		if !granted[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// CheckScopes returns an error naming the missing scopes if the auth
+// server granted fewer scopes than requested.
+func (c *Client) CheckScopes() error {
+	if missing := c.MissingScopes(); len(missing) > 0 {
+		return fmt.Errorf("Access token lacks the requested scopes: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package person_api
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+// NewClientWithTokenSource creates a client that asks ts for a token before
+// every request, leaving caching and refreshing to the source. Like
+// NewClientWithToken it holds no client credentials: RefreshAccessToken
+// fails with ErrNoCredentials and a 401 is reported as an
+// *UnauthorizedError.
+func NewClientWithTokenSource(ts oauth2.TokenSource, baseUrl string, opts ...Option) (*Client, error) {
+	if ts == nil {
+		return nil, fmt.Errorf("Token source must not be nil")
+	}
+	c, err := newClient("", "", append([]Option{WithBaseURL(baseUrl)}, opts...))
+	if err != nil {
+		return nil, err
+	}
+	if c.autoRefresh > 0 {
+		return nil, fmt.Errorf("Auto refresh needs client credentials")
+	}
+	c.tokenSource = ts
+	return c, nil
+}
+
+// TokenSource returns an oauth2.TokenSource handing out the access tokens of
+// c, refreshing them as the client itself would, so they can be shared with
+// other libraries.
+func (c *Client) TokenSource() oauth2.TokenSource {
+	if c.tokenSource != nil {
+		return c.tokenSource
+	}
+	return clientTokenSource{c}
+}
+
+type clientTokenSource struct {
+	c *Client
+}
+
+func (s clientTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.c.freshToken(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		Expiry:      s.c.TokenExpiresAt(),
+	}, nil
+}
+
+// sourceToken fetches the current token from the client's token source.
+func (c *Client) sourceToken() (string, error) {
+	tok, err := c.tokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("Token source failed: %w", c.redactError(err))
+	}
+	return tok.AccessToken, nil
+}
@@ -0,0 +1,69 @@
+package person_api
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer creates spans for the operations of a client, see WithTracer. The
+// spans of a request are children of the span in its context, if any. The
+// oteltrace package adapts it to OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// End finishes the span, marking it failed if err is not nil.
+	End(err error)
+}
+
+// HeaderInjector may be implemented by a Tracer to propagate the trace
+// context of ctx to the Person API, e.g. as a traceparent header.
+type HeaderInjector interface {
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span attributes set by the client.
+const (
+	AttrEndpoint   = "person_api.endpoint"
+	AttrStatusCode = "http.status_code"
+	AttrRetries    = "person_api.retries"
+)
+
+// requestSpanName is the name of the span of each authenticated request,
+// e.g. every page of an enumeration.
+const requestSpanName = "person_api.request"
+
+type spanKey struct{}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) End(error)                        {}
+
+// startSpan starts a span if a tracer is configured and keeps it in the
+// returned context for spanFromContext.
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := c.tracer.Start(ctx, name)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// spanFromContext returns the innermost span started by the client.
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// injectTraceHeaders propagates the trace context into req.
+func (c *Client) injectTraceHeaders(ctx context.Context, req *http.Request) {
+	if injector, ok := c.tracer.(HeaderInjector); ok {
+		injector.Inject(ctx, req.Header)
+	}
+}
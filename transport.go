@@ -0,0 +1,239 @@
+package person_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// RoundTripperFunc adapts a function to http.RoundTripper, for writing
+// middlewares for WithTransportMiddleware.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// tokenRequestKey marks the context of token requests, which must not
+// carry a bearer token themselves.
+type tokenRequestKey struct{}
+
+// buildTransport assembles the chain every request passes through,
+// outermost first:
+//
+//	bearer token injection and 401 handling, skipped for token requests
+//	the middlewares given to WithTransportMiddleware, in order
+//	retries per the retry policy
+//	the rate limiter
+//	the circuit breaker
+//	stats
+//	the debug dump
+//	the http.Client, whose timeout applies to each attempt
+func (c *Client) buildTransport() http.RoundTripper {
+	var rt http.RoundTripper = RoundTripperFunc(c.send)
+	if c.debugWriter != nil {
+		rt = &debugTransport{next: rt, w: c.debugWriter, limit: c.debugBodyLimit, redact: c.redact}
+	}
+	if c.stats != nil {
+		rt = c.statsMiddleware(rt)
+	}
+	if c.breaker != nil {
+		rt = c.circuitMiddleware(rt)
+	}
+	if c.limiter != nil {
+		rt = c.rateLimitMiddleware(rt)
+	}
+	rt = c.retryMiddleware(rt)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		rt = c.middlewares[i](rt)
+	}
+	return c.authMiddleware(rt)
+}
+
+// send is the innermost layer. When the request's context has been
+// cancelled or has expired it reports the context error instead of the
+// transport error, whose URL is redacted.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = c.redactString(urlErr.URL)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// withoutURL strips the request URL from a transport error for logging,
+// since lookup URLs carry the email or user id looked up.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// authMiddleware sends requests with the current access token. If the API
+// answers 401 the token is refreshed and the request retried once, unless
+// the client has no credentials to refresh it with.
+func (c *Client) authMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		if ctx.Value(tokenRequestKey{}) != nil {
+			return next.RoundTrip(req)
+		}
+		token, err := c.freshToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(withBearer(req, token))
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || !c.hasCredentials() {
+			return resp, err
+		}
+		drainAndClose(resp.Body)
+
+		if err := c.refreshStaleToken(ctx, token); err != nil {
+			return nil, err
+		}
+		retry, err := replay(req)
+		if err != nil {
+			return nil, err
+		}
+		return next.RoundTrip(withBearer(retry, c.getToken()))
+	})
+}
+
+// withBearer returns a copy of req carrying token, since a RoundTripper
+// must not modify its request.
+func withBearer(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// replay returns a copy of req with a fresh body, for sending it again.
+// Requests with a body must be replayable via GetBody.
+func replay(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// retryMiddleware retries transport errors, 5xx and 429 responses of
+// idempotent requests according to the retry policy. A Retry-After header on
+// a 429 overrides the backoff.
+func (c *Client) retryMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		attempt := 1
+		defer func() {
+			spanFromContext(ctx).SetAttribute(AttrRetries, attempt-1)
+		}()
+		for ; ; attempt++ {
+			resp, err := next.RoundTrip(req)
+			if ctx.Err() != nil || attempt >= c.retryPolicy.MaxAttempts || !isIdempotent(req) || !isRetryable(resp, err) {
+				return resp, err
+			}
+
+			delay := c.retryPolicy.delay(attempt)
+			if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+				if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+					delay = d
+				}
+				// Waiting beyond the deadline would only turn the 429
+				// into a less helpful context error.
+				if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+					return resp, err
+				}
+				atomic.AddInt64(c.throttledRetries, 1)
+			}
+
+			if resp != nil {
+				c.logger.Warn("Retrying request", "method", req.Method, "endpoint", c.endpointLabel(req.URL),
+					"attempt", attempt, "status", resp.StatusCode, "delay", delay)
+				drainAndClose(resp.Body)
+			} else {
+				c.logger.Warn("Retrying request", "method", req.Method, "endpoint", c.endpointLabel(req.URL),
+					"attempt", attempt, "error", withoutURL(err), "delay", delay)
+			}
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+
+			if req, err = replay(req); err != nil {
+				return nil, err
+			}
+		}
+	})
+}
+
+// isIdempotent reports whether req may be sent again after a failure. Token
+// requests are POSTs, but repeating one at most issues another token.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return req.Context().Value(tokenRequestKey{}) != nil
+}
+
+func (c *Client) rateLimitMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		waited, err := c.limiter.wait(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		if waited > 0 {
+			c.logger.Debug("Waited for the rate limiter", "endpoint", c.endpointLabel(req.URL), "wait", waited)
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+func (c *Client) circuitMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := c.breaker.allow(); err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(req)
+		if req.Context().Err() != nil {
+			c.breaker.abort()
+		} else {
+			c.breaker.record(err != nil || resp.StatusCode >= 500)
+		}
+		return resp, err
+	})
+}
+
+func (c *Client) statsMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+		}
+		c.stats.ObserveRequest(req.Method, c.endpointLabel(req.URL), status, time.Since(start))
+		return resp, err
+	})
+}
+
+// withTokenRequest marks ctx as belonging to a token request.
+func withTokenRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tokenRequestKey{}, true)
+}
@@ -0,0 +1,41 @@
+package person_api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// normalizeURL checks that raw, the URL described by name, is an absolute
+// http or https URL and returns it without trailing slashes so that paths
+// can be appended with joinPath.
+func normalizeURL(name, raw string) (string, error) {
+	if raw == "" {
+		return "", fmt.Errorf("%s must not be empty", name)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%s %q is invalid: %v", name, raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%s %q must use http or https", name, raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%s %q has no host", name, raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("%s %q must not have a query or fragment", name, raw)
+	}
+	return strings.TrimRight(raw, "/"), nil
+}
+
+// joinPath appends elems to base with exactly one slash between each, so
+// that neither a base URL with a trailing slash nor a path with a leading
+// one produces "//". A trailing slash of the last element is kept.
+func joinPath(base string, elems ...string) string {
+	joined := base
+	for _, elem := range elems {
+		joined = strings.TrimRight(joined, "/") + "/" + strings.TrimLeft(elem, "/")
+	}
+	return joined
+}
@@ -0,0 +1,94 @@
+package person_api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// UserID identifies a user as listed by /v2/users/id/all. PrimaryEmail is
+// only set when the endpoint includes it.
+type UserID struct {
+	UserID       string `json:"user_id"`
+	PrimaryEmail string `json:"primary_email,omitempty"`
+}
+
+// UnmarshalJSON accepts both a bare user_id string and an object.
+func (u *UserID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		*u = UserID{}
+		return json.Unmarshal(data, &u.UserID)
+	}
+	type plain UserID
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+type getAllUserIDsResp struct {
+	Users    []UserID  `json:"users"`
+	NextPage rawCursor `json:"nextPage"`
+}
+
+// GetAllUserIDs lists the identifiers of all users without fetching their
+// profiles.
+func (c *Client) GetAllUserIDs(ctx context.Context) ([]UserID, error) {
+	return c.GetAllUserIDsWithQuery(ctx, UsersQuery{})
+}
+
+// GetAllUserIDsWithQuery is GetAllUserIDs restricted by q. Since no profiles
+// are returned, q is only applied server-side.
+func (c *Client) GetAllUserIDsWithQuery(ctx context.Context, q UsersQuery) (ids []UserID, err error) {
+	ctx, cancel := c.enumerationContext(ctx)
+	defer cancel()
+	ctx, span := c.startSpan(ctx, "GetAllUserIDs")
+	defer func() { span.End(err) }()
+
+	idsUrl, err := url.Parse(joinPath(c.baseUrl, "/v2/users/id/all"))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		allIds []UserID
+		cursor rawCursor
+		guard  = c.newPageGuard("")
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		params := q.values()
+		if cursor != "" {
+			params.Set("nextPage", string(cursor))
+		}
+		idsUrl.RawQuery = params.Encode()
+
+		resp, err := c.getAuthenticated(acceptGzip(ctx), idsUrl.String())
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode >= 400 {
+			return nil, c.newAPIError(resp)
+		}
+
+		var idsResp getAllUserIDsResp
+		if err := decodeAndClose(resp, &idsResp); err != nil {
+			return nil, err
+		}
+		if err := guard.next(len(idsResp.Users), string(idsResp.NextPage)); err != nil {
+			return nil, err
+		}
+		allIds = append(allIds, idsResp.Users...)
+		c.logger.Debug("Fetched a page of user ids", "endpoint", "/v2/users/id/all", "count", len(idsResp.Users), "cursor", string(cursor))
+
+		if idsResp.NextPage == "" {
+			break
+		}
+		cursor = idsResp.NextPage
+	}
+
+	return allIds, nil
+}
@@ -0,0 +1,384 @@
+package person_api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Connections known to the Person API for filtering user listings. Other
+// values are passed through unchanged so that new connections keep working.
+const (
+	ConnectionAD              = "ad"
+	ConnectionGithub          = "github"
+	ConnectionEmail           = "email"
+	ConnectionGoogleOAuth2    = "google-oauth2"
+	ConnectionFirefoxAccounts = "firefoxaccounts"
+)
+
+// UsersQuery restricts a user listing. The zero value lists everyone.
+type UsersQuery struct {
+	// Connection only lists users who log in through the given connection,
+	// such as ConnectionAD.
+	Connection string
+	// Active, when set, only lists active or inactive users. The filter is
+	// also applied locally in case the server ignores it.
+	Active *bool
+}
+
+// Bool returns a pointer to v, for use in UsersQuery.
+func Bool(v bool) *bool {
+	return &v
+}
+
+func (q UsersQuery) matches(p *Person) bool {
+	if q.Active != nil && p.Active.Value != *q.Active {
+		return false
+	}
+	return true
+}
+
+// filter drops the persons not matching q as well as null items.
+func (q UsersQuery) filter(persons []*Person) []*Person {
+	filtered := persons[:0]
+	for _, p := range persons {
+		if p != nil && q.matches(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+func (q UsersQuery) values() url.Values {
+	v := url.Values{}
+	if q.Connection != "" {
+		v.Set("connectionMethod", q.Connection)
+	}
+	if q.Active != nil {
+		if *q.Active {
+			v.Set("active", "True")
+		} else {
+			v.Set("active", "False")
+		}
+	}
+	return v
+}
+
+type getAllUsersResp struct {
+	Items    []*Person  `json:"Items"`
+	NextPage pageCursor `json:"nextPage"`
+}
+
+// UsersPage is a single page of /v2/users.
+type UsersPage struct {
+	Items []*Person
+	// NextCursor fetches the following page when passed to GetUsersPage.
+	// It is opaque, safe to persist, and empty on the last page.
+	NextCursor string
+}
+
+// GetUsersPage fetches the page of users identified by cursor, starting with
+// the first page when cursor is empty.
+func (c *Client) GetUsersPage(ctx context.Context, cursor string) (*UsersPage, error) {
+	return c.GetUsersPageWithQuery(ctx, UsersQuery{}, cursor)
+}
+
+// GetUsersPageWithQuery is GetUsersPage restricted by q. A cursor must be
+// used with the query that produced it.
+func (c *Client) GetUsersPageWithQuery(ctx context.Context, q UsersQuery, cursor string) (*UsersPage, error) {
+	usersUrl, err := url.Parse(joinPath(c.baseUrl, "/v2/users"))
+	if err != nil {
+		return nil, err
+	}
+	params := q.values()
+	if cursor != "" {
+		params.Set("nextPage", cursor)
+	}
+	usersUrl.RawQuery = params.Encode()
+
+	resp, err := c.getAuthenticated(acceptGzip(ctx), usersUrl.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, c.newAPIError(resp)
+	}
+
+	decodePerson := decodePersonLax
+	if c.strictDecoding {
+		decodePerson = decodePersonStrict
+	}
+	uResp, err := decodeUsersPage(resp, decodePerson)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Fetched a page of users", "endpoint", "/v2/users", "count", len(uResp.Items), "cursor", cursor)
+	return &UsersPage{Items: q.filter(uResp.Items), NextCursor: string(uResp.NextPage)}, nil
+}
+
+// GetAllUsers lists every user of /v2/users. If a page fails or exceeds a
+// limit set with WithMaxPages or WithMaxUsers, the users of the pages fetched
+// before it are returned together with a *PageError, so the slice may be
+// non-nil even though err is not. Its Cursor can be passed
+// to GetUsersPage to resume the enumeration.
+func (c *Client) GetAllUsers(ctx context.Context) ([]*Person, error) {
+	return c.GetAllUsersWithQuery(ctx, UsersQuery{})
+}
+
+// GetAllUsersByConnection lists the users of a single connection, such as
+// ConnectionGithub.
+func (c *Client) GetAllUsersByConnection(ctx context.Context, connection string) ([]*Person, error) {
+	if connection == "" {
+		return nil, fmt.Errorf("Connection must not be empty")
+	}
+	return c.GetAllUsersWithQuery(ctx, UsersQuery{Connection: connection})
+}
+
+// GetAllUsersWithQuery is GetAllUsers restricted by q, returning partial
+// results with a *PageError in the same way. Resume with
+// GetUsersPageWithQuery and the same q.
+func (c *Client) GetAllUsersWithQuery(ctx context.Context, q UsersQuery) (persons []*Person, err error) {
+	ctx, cancel := c.enumerationContext(ctx)
+	defer cancel()
+	ctx, span := c.startSpan(ctx, "GetAllUsers")
+	defer func() { span.End(err) }()
+
+	var (
+		allUsers []*Person
+		cursor   string
+		guard    = c.newPageGuard("")
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return allUsers, &PageError{cursor: cursor, Err: err}
+		}
+
+		page, err := c.GetUsersPageWithQuery(ctx, q, cursor)
+		if err != nil {
+			return allUsers, &PageError{cursor: cursor, Err: err}
+		}
+		if err := guard.next(len(page.Items), page.NextCursor); err != nil {
+			return allUsers, &PageError{cursor: cursor, Err: err}
+		}
+		allUsers = append(allUsers, page.Items...)
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	return allUsers, nil
+}
+
+// StreamAllUsers enumerates /v2/users lazily, fetching the next page only
+// once every person of the current one has been received. Both channels are
+// closed when the enumeration ends; a failure is delivered on the error
+// channel after the persons sent before it. Consumers that stop reading
+// early must cancel ctx to stop the enumeration.
+//
+//	persons, errs := client.StreamAllUsers(ctx)
+//	for p := range persons {
+//		...
+//	}
+//	if err := <-errs; err != nil {
+//		...
+//	}
+func (c *Client) StreamAllUsers(ctx context.Context) (<-chan *Person, <-chan error) {
+	return c.StreamUsersWithQuery(ctx, UsersQuery{})
+}
+
+func (c *Client) StreamUsersWithQuery(ctx context.Context, q UsersQuery) (<-chan *Person, <-chan error) {
+	persons := make(chan *Person)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(persons)
+
+		ctx, cancel := c.enumerationContext(ctx)
+		defer cancel()
+
+		var cursor string
+		guard := c.newPageGuard("")
+		for {
+			page, err := c.GetUsersPageWithQuery(ctx, q, cursor)
+			if err == nil {
+				err = guard.next(len(page.Items), page.NextCursor)
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, p := range page.Items {
+				select {
+				case persons <- p:
+				case <-ctx.Done():
+					errs <- ctx.Err()
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			cursor = page.NextCursor
+		}
+	}()
+
+	return persons, errs
+}
+
+// ErrStopIteration can be returned by the callback of ForEachUser to stop
+// the enumeration without an error.
+var ErrStopIteration = errors.New("Stop iteration")
+
+// ForEachUser calls fn for every person of /v2/users, page by page. If fn
+// returns ErrStopIteration no further pages are fetched and ForEachUser
+// returns nil; any other error aborts the enumeration and is returned
+// wrapped.
+func (c *Client) ForEachUser(ctx context.Context, fn func(*Person) error) error {
+	return c.ForEachUserWithQuery(ctx, UsersQuery{}, fn)
+}
+
+func (c *Client) ForEachUserWithQuery(ctx context.Context, q UsersQuery, fn func(*Person) error) error {
+	ctx, cancel := c.enumerationContext(ctx)
+	defer cancel()
+
+	var cursor string
+	guard := c.newPageGuard("")
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, err := c.GetUsersPageWithQuery(ctx, q, cursor)
+		if err != nil {
+			return err
+		}
+		if err := guard.next(len(page.Items), page.NextCursor); err != nil {
+			return err
+		}
+		for _, p := range page.Items {
+			if err := fn(p); err != nil {
+				if err == ErrStopIteration {
+					return nil
+				}
+				return fmt.Errorf("Callback failed for user %s: %w", p.UserID.Value, err)
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// decodeUsersPage decodes a /v2/users page and closes its body. The
+// profiles are decoded one at a time with decodePerson, so that unlike
+// decodeAndClose the decoder only ever buffers a single profile rather than
+// the whole page. Null profiles are kept as nil.
+func decodeUsersPage(resp *http.Response, decodePerson func(*json.Decoder) (*Person, error)) (*getAllUsersResp, error) {
+	defer resp.Body.Close()
+	body, err := decodedBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	var page getAllUsersResp
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		// encoding/json matches keys case-insensitively as well.
+		switch name, _ := key.(string); {
+		case strings.EqualFold(name, "Items"):
+			if page.Items, err = decodeUsersPageItems(dec, decodePerson); err != nil {
+				return nil, err
+			}
+		case strings.EqualFold(name, "nextPage"):
+			if err := dec.Decode(&page.NextPage); err != nil {
+				return nil, err
+			}
+		default:
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	return &page, err
+}
+
+// decodeUsersPageItems decodes the Items array of a page, which may be null.
+func decodeUsersPageItems(dec *json.Decoder, decodePerson func(*json.Decoder) (*Person, error)) ([]*Person, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("Malformed page of users: Items is %v, not an array", tok)
+	}
+	items := []*Person{}
+	for dec.More() {
+		p, err := decodePerson(dec)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, p)
+	}
+	return items, expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("Malformed page of users: expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+func decodePersonLax(dec *json.Decoder) (*Person, error) {
+	var p *Person
+	err := dec.Decode(&p)
+	return p, err
+}
+
+func decodePersonStrict(dec *json.Decoder) (*Person, error) {
+	var data json.RawMessage
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	p, err := UnmarshalPersonStrict(data)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
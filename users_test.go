@@ -0,0 +1,177 @@
+package person_api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	person_api "go.mozilla.org/person-api"
+	"go.mozilla.org/person-api/personapitest"
+)
+
+func TestGetAllUsersByConnectionPassesConnectionThrough(t *testing.T) {
+	for _, connection := range []string{"github", "Corp.LDAP", "ad|Mozilla-LDAP", "a b+c&d", "кириллица"} {
+		var got string
+		c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+			got = r.URL.Query().Get("connectionMethod")
+			w.Write([]byte(`{"Items": [], "nextPage": null}`))
+		})
+		if _, err := c.GetAllUsersByConnection(context.Background(), connection); err != nil {
+			t.Errorf("GetAllUsersByConnection(%q) failed: %v", connection, err)
+		}
+		s.Close()
+		if got != connection {
+			t.Errorf("GetAllUsersByConnection(%q) sent connectionMethod %q", connection, got)
+		}
+	}
+}
+
+func TestGetAllUsersByConnectionRejectsEmpty(t *testing.T) {
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request for %s", r.URL)
+	})
+	defer s.Close()
+
+	if _, err := c.GetAllUsersByConnection(context.Background(), ""); err == nil {
+		t.Error("GetAllUsersByConnection(\"\") succeeded")
+	}
+}
+
+func TestGetUsersPageDecoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantItems  int
+		wantCursor bool
+	}{
+		{"empty", `{"Items": [], "nextPage": null}`, 0, false},
+		{"null items", `{"Items": null, "nextPage": null}`, 0, false},
+		{"no items", `{"nextPage": null}`, 0, false},
+		{"null profile", `{"Items": [null, {"user_id": {"value": "a"}}], "nextPage": null}`, 1, false},
+		{"cursor first", `{"nextPage": {"id": "a"}, "Items": [{"user_id": {"value": "a"}}]}`, 1, true},
+		{"unknown keys", `{"Count": 1, "Items": [{"user_id": {"value": "a"}}], "extra": {"a": [1]}, "nextPage": "a"}`, 1, true},
+		{"lowercase keys", `{"items": [{"user_id": {"value": "a"}}], "nextpage": "a"}`, 1, true},
+	}
+	for _, strict := range []bool{false, true} {
+		for _, tt := range tests {
+			name := tt.name
+			var opts []person_api.Option
+			if strict {
+				name += " strict"
+				opts = append(opts, person_api.WithStrictDecoding())
+			}
+			t.Run(name, func(t *testing.T) {
+				c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(tt.body))
+				}, opts...)
+				defer s.Close()
+
+				page, err := c.GetUsersPage(context.Background(), "")
+				if err != nil {
+					t.Fatalf("GetUsersPage failed: %v", err)
+				}
+				if len(page.Items) != tt.wantItems || (page.NextCursor != "") != tt.wantCursor {
+					t.Errorf("got %d items and cursor %q, want %d items and a cursor: %v",
+						len(page.Items), page.NextCursor, tt.wantItems, tt.wantCursor)
+				}
+			})
+		}
+	}
+}
+
+func TestGetUsersPageMalformed(t *testing.T) {
+	for _, body := range []string{``, `[]`, `{"Items": {}}`, `{"Items": [`, `{"Items": [1]}`} {
+		c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		})
+		if _, err := c.GetUsersPage(context.Background(), ""); err == nil {
+			t.Errorf("GetUsersPage succeeded for the page %q", body)
+		}
+		s.Close()
+	}
+
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Items": [{"user_id": {"value": "a"}, "unknown": 1}], "nextPage": null}`))
+	}, person_api.WithStrictDecoding())
+	defer s.Close()
+	var strictErr *person_api.StrictDecodingError
+	if _, err := c.GetUsersPage(context.Background(), ""); !errors.As(err, &strictErr) || strictErr.UserID != "a" {
+		t.Errorf("GetUsersPage returned %v, want a *StrictDecodingError for a", err)
+	}
+}
+
+// BenchmarkGetAllUsers decodes a single uncompressed page of 1000 profiles.
+func BenchmarkGetAllUsers(b *testing.B) {
+	var written int64
+	c, s := newStubClient(b, pageHandler(b, usersPage(b, 1000), false, &written))
+	defer s.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.GetAllUsers(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGetAllUsersPartialResults(t *testing.T) {
+	persons := newTestPersons(10)
+	noRetries := person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1})
+
+	for _, tt := range []struct {
+		name     string
+		failPage int
+		opts     []person_api.Option
+		want     int
+		wantErr  error
+	}{
+		{"first page fails", 1, nil, 0, nil},
+		{"third page fails", 3, nil, 6, nil},
+		{"last page fails", 4, nil, 9, nil},
+		{"page limit", 0, []person_api.Option{person_api.WithMaxPages(2)}, 3, person_api.ErrLimitExceeded},
+		{"user limit", 0, []person_api.Option{person_api.WithMaxUsers(7)}, 6, person_api.ErrLimitExceeded},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := personapitest.NewServer(t, persons)
+			defer s.Close()
+			s.PageSize = 3
+			s.FailPage = tt.failPage
+			c, err := s.NewClient(append(tt.opts, noRetries)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			users, err := c.GetAllUsers(context.Background())
+			var pageErr *person_api.PageError
+			if !errors.As(err, &pageErr) {
+				t.Fatalf("GetAllUsers returned %v, want a *PageError", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetAllUsers returned %v, want %v", err, tt.wantErr)
+			}
+			if len(users) != tt.want {
+				t.Fatalf("GetAllUsers returned %d users with the error, want %d", len(users), tt.want)
+			}
+			if (pageErr.Cursor() == "") != (tt.want == 0) {
+				t.Errorf("PageError has cursor %q after %d users", pageErr.Cursor(), len(users))
+			}
+
+			// Resuming from the cursor yields the users that are missing.
+			healthy := personapitest.NewServer(t, persons)
+			defer healthy.Close()
+			healthy.PageSize = 3
+			c, err = healthy.NewClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, err := c.GetUsersPage(context.Background(), pageErr.Cursor())
+			if err != nil {
+				t.Fatalf("GetUsersPage failed: %v", err)
+			}
+			if len(page.Items) == 0 || page.Items[0].UserID.Value != persons[tt.want].UserID.Value {
+				t.Errorf("resumed page starts with %v, want %s", page.Items, persons[tt.want].UserID.Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package person_api
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Violation is a rule of the CIS profile schema broken by a person, located
+// by a JSON path such as "staff_information.team.metadata.display".
+type Violation struct {
+	Path    string
+	Message string
+}
+
+// ValidationError lists every violation found by ValidatePerson.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Path + ": " + v.Message
+	}
+	return fmt.Sprintf("Person has %d schema violations: %s", len(e.Violations), strings.Join(parts, "; "))
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	userIdPattern = regexp.MustCompile(`^[a-z0-9-]+\|\S+$`)
+	emailPattern  = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+
+	classifications = map[Classification]bool{
+		WORKGROUPCONFIDENTIALSTAFFONLY: true,
+		WORKGROUPCONFIDENTIAL:          true,
+		IndividualConfidential:         true,
+		MozillaConfidential:            true,
+		PUBLIC:                         true,
+	}
+	algs = map[Alg]bool{Ed25519: true, Hs256: true, RSA: true, Rs256: true}
+	typs = map[Typ]bool{Jws: true, PGP: true}
+
+	publishers = map[PublisherAuthority]bool{AccessProvider: true, Cis: true, Hris: true, LDAP: true, Mozilliansorg: true}
+)
+
+// ValidatePerson checks p against the rules of the CIS profile schema that
+// the Person API enforces on publishing: user_id and primary_email are
+// required, identifiers and timestamps are well formed, and the metadata
+// and signatures of every attribute hold known values. It returns a
+// *ValidationError listing all violations, or nil.
+func ValidatePerson(p *Person) error {
+	if p == nil {
+		return &ValidationError{Violations: []Violation{{Path: "$", Message: "person is nil"}}}
+	}
+	v := &validator{}
+
+	if p.UserID.Value == "" {
+		v.add("user_id.value", "is required")
+	} else if !userIdPattern.MatchString(p.UserID.Value) {
+		v.add("user_id.value", fmt.Sprintf("%q is not of the form connection|id", p.UserID.Value))
+	}
+	if p.PrimaryEmail.Value == "" {
+		v.add("primary_email.value", "is required")
+	} else if !emailPattern.MatchString(p.PrimaryEmail.Value) {
+		v.add("primary_email.value", fmt.Sprintf("%q is not an email address", p.PrimaryEmail.Value))
+	}
+	if p.UUID.Value != "" && !uuidPattern.MatchString(p.UUID.Value) {
+		v.add("uuid.value", fmt.Sprintf("%q is not a UUID", p.UUID.Value))
+	}
+	v.timestamp("created.value", p.Created.Value)
+	v.timestamp("last_modified.value", p.LastModified.Value)
+
+	v.walk(reflect.ValueOf(p).Elem(), "")
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(path, message string) {
+	v.violations = append(v.violations, Violation{Path: path, Message: message})
+}
+
+func (v *validator) timestamp(path, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := ParseTimestamp(value); err != nil {
+		v.add(path, err.Error())
+	}
+}
+
+// walk visits the attributes below val, named by their JSON keys.
+func (v *validator) walk(val reflect.Value, path string) {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if !val.IsNil() {
+			v.walk(val.Elem(), path)
+		}
+	case reflect.Struct:
+		switch attr := val.Interface().(type) {
+		case Metadata:
+			v.metadata(path, attr.Classification, attr.Display, attr.Created, attr.LastModified)
+			return
+		case AccessProviderMetadata:
+			display, _ := attr.Display.(string)
+			v.metadata(path, attr.Classification, DisplayLevel(display), attr.Created, attr.LastModified)
+			return
+		case Signature:
+			v.signature(path, attr)
+			return
+		}
+		t := val.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if t.Field(i).PkgPath != "" || name == "" || name == "-" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			v.walk(val.Field(i), name)
+		}
+	}
+}
+
+// metadata checks the metadata of an attribute. Attributes that are absent
+// have empty metadata, which is not reported.
+func (v *validator) metadata(path string, classification Classification, display DisplayLevel, created, lastModified string) {
+	if classification != "" && !classifications[classification] {
+		v.add(path+".classification", fmt.Sprintf("unknown classification %q", classification))
+	}
+	if _, ok := displayRanks[display]; display != "" && !ok {
+		v.add(path+".display", fmt.Sprintf("unknown display level %q", display))
+	}
+	v.timestamp(path+".created", created)
+	v.timestamp(path+".last_modified", lastModified)
+}
+
+func (v *validator) signature(path string, s Signature) {
+	publisher := s.Publisher
+	if publisher.Alg != "" && !algs[publisher.Alg] {
+		v.add(path+".publisher.alg", fmt.Sprintf("unknown algorithm %q", publisher.Alg))
+	}
+	if publisher.Typ != "" && !typs[publisher.Typ] {
+		v.add(path+".publisher.typ", fmt.Sprintf("unknown type %q", publisher.Typ))
+	}
+	if publisher.Name != "" && !publishers[publisher.Name] {
+		v.add(path+".publisher.name", fmt.Sprintf("unknown publisher %q", publisher.Name))
+	}
+	for i, additional := range s.Additional {
+		if additional.Alg != "" && !algs[additional.Alg] {
+			v.add(fmt.Sprintf("%s.additional[%d].alg", path, i), fmt.Sprintf("unknown algorithm %q", additional.Alg))
+		}
+		if additional.Typ != "" && !typs[additional.Typ] {
+			v.add(fmt.Sprintf("%s.additional[%d].typ", path, i), fmt.Sprintf("unknown type %q", additional.Typ))
+		}
+	}
+}
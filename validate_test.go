@@ -0,0 +1,79 @@
+package person_api
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestValidatePersonFixtures(t *testing.T) {
+	for name, data := range readFixtures(t, "valid/*.json") {
+		p, err := UnmarshalPerson(data)
+		if err != nil {
+			t.Fatalf("%s: UnmarshalPerson failed: %v", name, err)
+		}
+		if err := ValidatePerson(&p); err != nil {
+			t.Errorf("%s: ValidatePerson() = %v, want nil", name, err)
+		}
+	}
+
+	// The invalid fixtures are testdata/valid/contributor.json with the
+	// attributes at these paths broken.
+	tests := map[string][]string{
+		"missing_required.json": {
+			"primary_email.value",
+			"user_id.value",
+		},
+		"malformed_identifiers.json": {
+			"primary_email.value",
+			"user_id.value",
+			"uuid.value",
+		},
+		"bad_timestamps.json": {
+			"first_name.metadata.created",
+			"last_modified.value",
+		},
+		"unknown_enums.json": {
+			"active.metadata.classification",
+			"first_name.metadata.display",
+			"identities.github_id_v3.signature.publisher.name",
+			"identities.github_primary_email.signature.publisher.alg",
+		},
+	}
+	fixtures := readFixtures(t, "invalid/*.json")
+	if len(fixtures) != len(tests) {
+		t.Errorf("got %d invalid fixtures, want %d", len(fixtures), len(tests))
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, ok := fixtures[name]
+			if !ok {
+				t.Fatalf("no fixture %s", name)
+			}
+			p, err := UnmarshalPerson(data)
+			if err != nil {
+				t.Fatalf("UnmarshalPerson failed: %v", err)
+			}
+			var validationErr *ValidationError
+			if err := ValidatePerson(&p); !errors.As(err, &validationErr) {
+				t.Fatalf("ValidatePerson() = %v, want a *ValidationError", err)
+			}
+			var got []string
+			for _, v := range validationErr.Violations {
+				got = append(got, v.Path)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("violations at %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestValidatePersonNil(t *testing.T) {
+	var validationErr *ValidationError
+	if err := ValidatePerson(nil); !errors.As(err, &validationErr) || validationErr.Violations[0].Path != "$" {
+		t.Errorf("ValidatePerson(nil) = %v, want a violation at $", err)
+	}
+}
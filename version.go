@@ -0,0 +1,8 @@
+package person_api
+
+// Version is the version of this module, reported in the default
+// User-Agent.
+const Version = "0.1.0"
+
+// DefaultUserAgent identifies this client to the Person API operators.
+const DefaultUserAgent = "person-api-go/" + Version
@@ -0,0 +1,144 @@
+package person_api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type WatcherOptions struct {
+	// Fields are compared to detect modifications, DefaultDiffFields if
+	// empty.
+	Fields []DiffField
+	// EmitInitial reports every person of the first snapshot as added.
+	// Otherwise the first snapshot only establishes the baseline.
+	EmitInitial bool
+	// OnError is called when a sync fails; the next tick retries. If it is
+	// nil, failures are logged as warnings to the Logger of the client, see
+	// WithLogger.
+	OnError func(error)
+}
+
+// Watcher polls the directory and reports changes between consecutive
+// snapshots to the registered callbacks. Each poll enumerates all users:
+// an incremental fetch such as GetPersonsModifiedSince cannot tell that a
+// person was removed.
+type Watcher struct {
+	client   PersonAPI
+	interval time.Duration
+	opts     WatcherOptions
+
+	mu         sync.Mutex
+	onAdded    []func(*Person)
+	onRemoved  []func(*Person)
+	onModified []func(Modification)
+	snapshot   []*Person
+	synced     bool
+	lastSync   time.Time
+}
+
+func NewWatcher(client PersonAPI, interval time.Duration, opts WatcherOptions) (*Watcher, error) {
+	if client == nil {
+		return nil, fmt.Errorf("Watcher needs a client")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("Watcher interval must be positive, got %s", interval)
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = DefaultDiffFields
+	}
+	return &Watcher{client: client, interval: interval, opts: opts}, nil
+}
+
+func (w *Watcher) OnAdded(fn func(*Person)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onAdded = append(w.onAdded, fn)
+}
+
+func (w *Watcher) OnRemoved(fn func(*Person)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onRemoved = append(w.onRemoved, fn)
+}
+
+func (w *Watcher) OnModified(fn func(Modification)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onModified = append(w.onModified, fn)
+}
+
+// LastSync returns when the last successful sync finished, or the zero time.
+func (w *Watcher) LastSync() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastSync
+}
+
+// Start syncs immediately and then every interval until ctx is done, which
+// it returns. Callbacks run on the calling goroutine, one sync at a time.
+func (w *Watcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if err := w.sync(ctx); err != nil && ctx.Err() == nil {
+			w.reportError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) sync(ctx context.Context) error {
+	persons, err := w.client.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	previous, synced := w.snapshot, w.synced
+	w.snapshot, w.synced, w.lastSync = persons, true, time.Now()
+	onAdded, onRemoved, onModified := w.onAdded, w.onRemoved, w.onModified
+	w.mu.Unlock()
+
+	if !synced && !w.opts.EmitInitial {
+		return nil
+	}
+	cs := DiffSnapshotsFields(previous, persons, w.opts.Fields)
+	for _, p := range cs.Added {
+		for _, fn := range onAdded {
+			fn(p)
+		}
+	}
+	for _, p := range cs.Removed {
+		for _, fn := range onRemoved {
+			fn(p)
+		}
+	}
+	for _, m := range cs.Modified {
+		for _, fn := range onModified {
+			fn(m)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) reportError(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+		return
+	}
+	w.logger().Warn("Watcher sync failed", "error", err, "retry_in", w.interval)
+}
+
+// logger is the Logger of the watched client, if it has one.
+func (w *Watcher) logger() Logger {
+	if c, ok := w.client.(*Client); ok {
+		return c.logger
+	}
+	return nopLogger{}
+}
@@ -0,0 +1,42 @@
+package person_api_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+func TestWatcherLogsFailuresToClientLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	c, s := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}, person_api.WithLogger(logger), person_api.WithRetryPolicy(person_api.RetryPolicy{MaxAttempts: 1}))
+	defer s.Close()
+
+	w, err := person_api.NewWatcher(c, time.Hour, person_api.WatcherOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Start(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, line := range logger.Lines() {
+			if strings.HasPrefix(line, "WARN Watcher sync failed") {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no warning about the failed sync was logged: %q", logger.Lines())
+}
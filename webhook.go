@@ -0,0 +1,215 @@
+package person_api
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWebhookIssuer  = "https://auth.mozilla.auth0.com/"
+	DefaultWebhookJWKSURL = "https://auth.mozilla.auth0.com/.well-known/jwks.json"
+
+	// maxWebhookBodySize bounds the notification payloads read by
+	// WebhookHandler; CIS sends a few hundred bytes.
+	maxWebhookBodySize = 64 << 10
+)
+
+// WebhookEvent is a CIS change notification. It only names the affected
+// user, the profile itself is fetched with GetPersonByUserId.
+type WebhookEvent struct {
+	// Operation is the kind of change, such as "create", "update" or
+	// "delete".
+	Operation string
+	UserID    string
+	Time      time.Time
+}
+
+type webhookPayload struct {
+	Operation string      `json:"operation"`
+	Id        string      `json:"id"`
+	Time      json.Number `json:"time"`
+}
+
+type WebhookConfig struct {
+	// Issuer must match the iss claim of the bearer token,
+	// DefaultWebhookIssuer if empty.
+	Issuer string
+	// Audience is required and must be one of the aud claims of the bearer
+	// token.
+	Audience string
+	// JWKSURL serves the keys that sign the bearer tokens,
+	// DefaultWebhookJWKSURL if empty.
+	JWKSURL string
+	// HTTPClient fetches the JWKS, a client with DefaultTimeout if nil.
+	HTTPClient *http.Client
+	// JWKSCacheTTL is how long fetched keys are trusted, an hour if zero.
+	JWKSCacheTTL time.Duration
+	// Leeway tolerates clock skew when checking exp and nbf, a minute if
+	// zero.
+	Leeway time.Duration
+}
+
+// WebhookHandler is an http.Handler receiving CIS change notifications. It
+// answers 401 to requests without a valid bearer token, 400 to malformed
+// payloads and 500 when the callback fails, which makes CIS retry.
+type WebhookHandler struct {
+	issuer   string
+	audience string
+	leeway   time.Duration
+	jwks     *jwksCache
+	callback func(context.Context, WebhookEvent) error
+}
+
+var _ http.Handler = (*WebhookHandler)(nil)
+
+func NewWebhookHandler(config WebhookConfig, callback func(context.Context, WebhookEvent) error) (*WebhookHandler, error) {
+	if callback == nil {
+		return nil, fmt.Errorf("WebhookHandler needs a callback")
+	}
+	if config.Issuer == "" {
+		config.Issuer = DefaultWebhookIssuer
+	}
+	if config.Audience == "" {
+		return nil, fmt.Errorf("WebhookHandler needs an audience")
+	}
+	if config.JWKSURL == "" {
+		config.JWKSURL = DefaultWebhookJWKSURL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	if config.JWKSCacheTTL <= 0 {
+		config.JWKSCacheTTL = time.Hour
+	}
+	if config.Leeway <= 0 {
+		config.Leeway = time.Minute
+	}
+	return &WebhookHandler{
+		issuer:   config.Issuer,
+		audience: config.Audience,
+		leeway:   config.Leeway,
+		jwks: &jwksCache{
+			url:        config.JWKSURL,
+			httpClient: config.HTTPClient,
+			ttl:        config.JWKSCacheTTL,
+			minRefresh: time.Minute,
+		},
+		callback: callback,
+	}, nil
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.authenticate(r); err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	event, err := parseWebhookEvent(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.callback(r.Context(), event); err != nil {
+		http.Error(w, "processing the event failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+type webhookClaims struct {
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+}
+
+func (h *WebhookHandler) authenticate(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return fmt.Errorf("Missing bearer token")
+	}
+	payload, err := verifyJWS(strings.TrimSpace(auth[7:]), func(header jwsHeader) (crypto.PublicKey, error) {
+		if header.Alg != "RS256" {
+			return nil, fmt.Errorf("%w: unexpected algorithm %q", errInvalidSignature, header.Alg)
+		}
+		return h.jwks.key(r.Context(), header.Kid)
+	})
+	if err != nil {
+		return err
+	}
+	var claims webhookClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("Malformed token claims: %w", err)
+	}
+	if claims.Issuer != h.issuer {
+		return fmt.Errorf("Unexpected issuer %q", claims.Issuer)
+	}
+	if !audienceContains(claims.Audience, h.audience) {
+		return fmt.Errorf("Token not issued for %q", h.audience)
+	}
+	now := time.Now()
+	if claims.ExpiresAt == nil || now.After(time.Unix(*claims.ExpiresAt, 0).Add(h.leeway)) {
+		return fmt.Errorf("Token expired")
+	}
+	if claims.NotBefore != nil && now.Add(h.leeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return fmt.Errorf("Token not yet valid")
+	}
+	return nil
+}
+
+// audienceContains accepts the aud claim as a single string or a list.
+func audienceContains(aud json.RawMessage, audience string) bool {
+	var single string
+	if err := json.Unmarshal(aud, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(aud, &list); err != nil {
+		return false
+	}
+	for _, a := range list {
+		if a == audience {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWebhookEvent(body io.Reader) (WebhookEvent, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxWebhookBodySize+1))
+	if err != nil {
+		return WebhookEvent{}, fmt.Errorf("Reading the event failed: %w", err)
+	}
+	if len(data) > maxWebhookBodySize {
+		return WebhookEvent{}, errors.New("Event too large")
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return WebhookEvent{}, fmt.Errorf("Malformed event: %w", err)
+	}
+	if payload.Operation == "" || payload.Id == "" {
+		return WebhookEvent{}, errors.New("Event needs an operation and an id")
+	}
+	event := WebhookEvent{Operation: payload.Operation, UserID: payload.Id}
+	if payload.Time != "" {
+		secs, err := payload.Time.Float64()
+		if err != nil {
+			return WebhookEvent{}, fmt.Errorf("Malformed event time %q", payload.Time)
+		}
+		event.Time = time.Unix(0, int64(secs*float64(time.Second)))
+	}
+	return event, nil
+}
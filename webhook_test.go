@@ -0,0 +1,221 @@
+package person_api_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	person_api "go.mozilla.org/person-api"
+)
+
+const (
+	webhookIssuer   = "https://auth.example.com/"
+	webhookAudience = "hook.example.com"
+)
+
+// webhookIdP serves the JWKS of a locally generated key pair and signs
+// tokens with it.
+type webhookIdP struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	fetches int64
+	// status, if set, is answered to JWKS requests instead of the keys.
+	status int
+	delay  time.Duration
+}
+
+func newWebhookIdP(t *testing.T) *webhookIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &webhookIdP{key: key}
+	idp.Server = httptest.NewServer(http.HandlerFunc(idp.serveJWKS))
+	return idp
+}
+
+func (idp *webhookIdP) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&idp.fetches, 1)
+	time.Sleep(idp.delay)
+	if idp.status != 0 {
+		http.Error(w, "unavailable", idp.status)
+		return
+	}
+	pub := idp.key.PublicKey
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (idp *webhookIdP) Fetches() int64 {
+	return atomic.LoadInt64(&idp.fetches)
+}
+
+// sign returns an RS256 JWS of claims signed by key under kid.
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss": webhookIssuer,
+		"aud": []string{webhookAudience},
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newWebhookHandler(t *testing.T, idp *webhookIdP, events chan<- person_api.WebhookEvent) *person_api.WebhookHandler {
+	t.Helper()
+	h, err := person_api.NewWebhookHandler(person_api.WebhookConfig{
+		Issuer:   webhookIssuer,
+		Audience: webhookAudience,
+		JWKSURL:  idp.URL,
+	}, func(ctx context.Context, e person_api.WebhookEvent) error {
+		if events != nil {
+			events <- e
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func postEvent(h http.Handler, token, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+const webhookBody = `{"operation": "update", "id": "ad|Mozilla-LDAP|user1", "time": 1600000000}`
+
+func TestWebhookHandler(t *testing.T) {
+	idp := newWebhookIdP(t)
+	defer idp.Close()
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		claims[key] = value
+		return claims
+	}
+	valid := sign(t, idp.key, "k1", validClaims())
+
+	tests := []struct {
+		name   string
+		token  string
+		body   string
+		status int
+	}{
+		{"valid", valid, webhookBody, http.StatusOK},
+		{"no token", "", webhookBody, http.StatusUnauthorized},
+		{"malformed token", "not.a.jws", webhookBody, http.StatusUnauthorized},
+		{"signed by another key", sign(t, otherKey, "k1", validClaims()), webhookBody, http.StatusUnauthorized},
+		{"unknown key id", sign(t, idp.key, "k2", validClaims()), webhookBody, http.StatusUnauthorized},
+		{"tampered payload", valid[:strings.Index(valid, ".")+1] + base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"x"}`)) + valid[strings.LastIndex(valid, "."):], webhookBody, http.StatusUnauthorized},
+		{"wrong issuer", sign(t, idp.key, "k1", with("iss", "https://evil.example.com/")), webhookBody, http.StatusUnauthorized},
+		{"wrong audience", sign(t, idp.key, "k1", with("aud", "other.example.com")), webhookBody, http.StatusUnauthorized},
+		{"expired", sign(t, idp.key, "k1", with("exp", time.Now().Add(-time.Hour).Unix())), webhookBody, http.StatusUnauthorized},
+		{"not yet valid", sign(t, idp.key, "k1", with("nbf", time.Now().Add(time.Hour).Unix())), webhookBody, http.StatusUnauthorized},
+		{"malformed payload", valid, `{"operation": `, http.StatusBadRequest},
+		{"payload without id", valid, `{"operation": "update"}`, http.StatusBadRequest},
+		{"malformed time", valid, `{"operation": "update", "id": "x", "time": "soon"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make(chan person_api.WebhookEvent, 1)
+			h := newWebhookHandler(t, idp, events)
+			if got := postEvent(h, tt.token, tt.body); got != tt.status {
+				t.Fatalf("got status %d, want %d", got, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			want := person_api.WebhookEvent{Operation: "update", UserID: "ad|Mozilla-LDAP|user1", Time: time.Unix(1600000000, 0)}
+			if got := <-events; got.Operation != want.Operation || got.UserID != want.UserID || !got.Time.Equal(want.Time) {
+				t.Errorf("callback got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestWebhookHandlerSharesJWKSFetches sends a burst of requests to a handler
+// whose key set is slow to fetch, then to one whose IdP is down.
+func TestWebhookHandlerSharesJWKSFetches(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status int
+		want   int
+	}{
+		{"slow", 0, http.StatusOK},
+		{"failing", http.StatusServiceUnavailable, http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newWebhookIdP(t)
+			defer idp.Close()
+			idp.delay = 100 * time.Millisecond
+			idp.status = tt.status
+			h := newWebhookHandler(t, idp, nil)
+			token := sign(t, idp.key, "k1", validClaims())
+
+			var wg sync.WaitGroup
+			codes := make(chan int, 20)
+			for i := 0; i < cap(codes); i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					codes <- postEvent(h, token, webhookBody)
+				}()
+			}
+			wg.Wait()
+			close(codes)
+			for code := range codes {
+				if code != tt.want {
+					t.Errorf("got status %d, want %d", code, tt.want)
+				}
+			}
+
+			// Another token with an unknown key id would refetch, unless
+			// the keys were just fetched or the fetch just failed.
+			postEvent(h, sign(t, idp.key, "k2", validClaims()), webhookBody)
+			if got := idp.Fetches(); got != 1 {
+				t.Errorf("the JWKS was fetched %d times, want 1", got)
+			}
+		})
+	}
+}